@@ -4,6 +4,8 @@ import (
 	"github.com/dorianim/downtimerobot/internal/announcements"
 	"github.com/dorianim/downtimerobot/internal/crawler"
 	"github.com/dorianim/downtimerobot/internal/frontend"
+	"github.com/dorianim/downtimerobot/internal/metrics"
+	"github.com/dorianim/downtimerobot/internal/server"
 	"github.com/dorianim/downtimerobot/internal/statistics"
 	"github.com/spf13/cobra"
 )
@@ -16,15 +18,31 @@ var frontendCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, args []string) {
 		crawledServices, err := crawler.LoadServices()
 		cobra.CheckErr(err)
-		serviceList, serviceDetailList, err := statistics.Generate(crawledServices)
-		cobra.CheckErr(err)
-		announcementList, err := announcements.Generate()
-		cobra.CheckErr(err)
-		err = frontend.Generate(serviceList, serviceDetailList, announcementList)
+		_, err = generate(crawledServices)
 		cobra.CheckErr(err)
 	},
 }
 
+// generate generates the statistics, the metrics and the frontend from the historic data of the services
+func generate(crawledServices []crawler.Service) (server.Data, error) {
+	data := server.Data{}
+	var err error
+	data.ServiceList, data.ServiceDetailList, err = statistics.Generate(crawledServices)
+	if err != nil {
+		return data, err
+	}
+	data.AnnouncementList, err = announcements.Generate()
+	if err != nil {
+		return data, err
+	}
+	data.IncidentList = statistics.GenerateIncidents(crawledServices, data.AnnouncementList)
+	data.Metrics = metrics.Generate(crawledServices, data.ServiceList)
+	if err := metrics.WriteTextfile(data.Metrics); err != nil {
+		return data, err
+	}
+	return data, frontend.Generate(data.ServiceList, data.ServiceDetailList, data.AnnouncementList, data.IncidentList)
+}
+
 func init() {
 	rootCmd.AddCommand(frontendCmd)
 
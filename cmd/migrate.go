@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/dorianim/downtimerobot/internal/crawler"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var migrateFrom crawler.StorageConfig
+var migrateTo crawler.StorageConfig
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Move historic data between storage backends",
+	Long: `Copy the historic data of all services from one storage backend to another.
+The source defaults to the storage configured in downtimerobot.yml.
+Afterwards, configure the destination as storage in downtimerobot.yml.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		from := migrateFrom
+		if !cmd.Flags().Changed("from") && !cmd.Flags().Changed("from-path") {
+			var err error
+			from, err = crawler.LoadStorageConfig()
+			cobra.CheckErr(err)
+		}
+
+		count, err := crawler.MigrateHistoricData(from, migrateTo)
+		cobra.CheckErr(err)
+
+		log.WithFields(log.Fields{
+			"services": count,
+			"to":       migrateTo.Type,
+		}).Info("Migrated historic data")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(migrateCmd)
+
+	migrateCmd.Flags().StringVar(&migrateFrom.Type, "from", "", "storage type to read from: json, bbolt or append")
+	migrateCmd.Flags().StringVar(&migrateFrom.Path, "from-path", "", "file to read from (default depends on the type)")
+	migrateCmd.Flags().StringVar(&migrateTo.Type, "to", "", "storage type to write to: json, bbolt or append")
+	migrateCmd.Flags().StringVar(&migrateTo.Path, "to-path", "", "file to write to (default depends on the type)")
+	migrateCmd.MarkFlagRequired("to")
+}
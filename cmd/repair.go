@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/dorianim/downtimerobot/internal/crawler"
+	"github.com/dorianim/downtimerobot/internal/notifications"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// repairCmd represents the repair command
+var repairCmd = &cobra.Command{
+	Use:   "repair",
+	Short: "Recover corrupted historic data",
+	Long: `Load the historic data and the notification state and store them again.
+Corrupted files are moved aside and replaced by their backup,
+corrupted lines of the append storage are dropped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		count, err := crawler.RepairHistoricData()
+		cobra.CheckErr(err)
+		err = notifications.RepairNotificationState()
+		cobra.CheckErr(err)
+
+		log.WithFields(log.Fields{
+			"services": count,
+		}).Info("Repaired historic data")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(repairCmd)
+}
@@ -5,14 +5,13 @@ Copyright © 2022 NAME HERE <EMAIL ADDRESS>
 package cmd
 
 import (
-	"github.com/dorianim/downtimerobot/internal/announcements"
 	"github.com/dorianim/downtimerobot/internal/crawler"
-	"github.com/dorianim/downtimerobot/internal/frontend"
 	"github.com/dorianim/downtimerobot/internal/notifications"
-	"github.com/dorianim/downtimerobot/internal/statistics"
 	"github.com/spf13/cobra"
 )
 
+var skipValidation bool
+
 // runCmd represents the run command
 var runCmd = &cobra.Command{
 	Use:   "run",
@@ -20,15 +19,15 @@ var runCmd = &cobra.Command{
 	Long: `Checks all services and writes the result to the data files.
 In addition to that, it generates the static frontend and statistics.
 It also sends pending notifications.
-It is equal to running crawl, frontend and notify.`,
+It is equal to running crawl, frontend and notify.
+The config file is validated first, see validate.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if !skipValidation {
+			cobra.CheckErr(validateConfig())
+		}
 		crawledServices, err := crawler.CrawlServices()
 		cobra.CheckErr(err)
-		serviceList, serviceDetailList, err := statistics.Generate(crawledServices)
-		cobra.CheckErr(err)
-		announcementList, err := announcements.Generate()
-		cobra.CheckErr(err)
-		err = frontend.Generate(serviceList, serviceDetailList, announcementList)
+		_, err = generate(crawledServices)
 		cobra.CheckErr(err)
 		err = notifications.Notify(crawledServices)
 		cobra.CheckErr(err)
@@ -37,6 +36,7 @@ It is equal to running crawl, frontend and notify.`,
 
 func init() {
 	rootCmd.AddCommand(runCmd)
+	runCmd.Flags().BoolVar(&skipValidation, "skip-validation", false, "Run even if the config file has errors")
 
 	// Here you will define your flags and configuration settings.
 
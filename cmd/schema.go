@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dorianim/downtimerobot/internal/safefile"
+	"github.com/dorianim/downtimerobot/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+var schemaOutput string
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema of the config file",
+	Long: `Prints a JSON Schema of downtimerobot.yml, which editors use for autocompletion and validation.
+With the YAML language server, add this line to the top of downtimerobot.yml:
+# yaml-language-server: $schema=./downtimerobot.schema.json`,
+	Run: func(cmd *cobra.Command, args []string) {
+		content, err := schema.JSONSchema(configs()...)
+		cobra.CheckErr(err)
+
+		if len(schemaOutput) == 0 {
+			fmt.Println(string(content))
+			return
+		}
+		cobra.CheckErr(safefile.WriteFile(schemaOutput, append(content, '\n'), 0644))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+	schemaCmd.Flags().StringVarP(&schemaOutput, "output", "o", "", "File to write the schema to instead of stdout")
+}
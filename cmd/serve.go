@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dorianim/downtimerobot/internal/crawler"
+	"github.com/dorianim/downtimerobot/internal/metrics"
+	"github.com/dorianim/downtimerobot/internal/notifications"
+	"github.com/dorianim/downtimerobot/internal/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Keep running and crawl every service in its interval",
+	Long: `Crawls every service in its own interval (interval in downtimerobot.yml, default 1m).
+After every crawl, the statistics and the frontend are generated and pending notifications are sent.
+The frontend and its data are served on the configured server address (default :8080).
+Stops gracefully on SIGTERM or SIGINT.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		statusServer, err := server.New()
+		cobra.CheckErr(err)
+		serverErrs := make(chan error, 1)
+		if statusServer != nil {
+			go func() {
+				err := statusServer.Run(ctx)
+				if err != nil {
+					// without the server, the daemon is useless
+					stop()
+				}
+				serverErrs <- err
+			}()
+		}
+
+		err = crawler.Watch(ctx, func(crawledServices []crawler.Service) {
+			data, err := generate(crawledServices)
+			if err != nil {
+				log.WithFields(log.Fields{
+					"err": err.Error(),
+				}).Error("Error generating the frontend")
+			} else if statusServer != nil {
+				// the counters only grow while serve is running, so they are served but not written to the textfile
+				data.Metrics = append(data.Metrics, metrics.GenerateCounters(crawledServices)...)
+				statusServer.Update(data)
+			}
+			if err := notifications.Notify(crawledServices); err != nil {
+				log.WithFields(log.Fields{
+					"err": err.Error(),
+				}).Error("Error sending notifications")
+			}
+		})
+		cobra.CheckErr(err)
+		if statusServer != nil {
+			stop()
+			cobra.CheckErr(<-serverErrs)
+		}
+		log.Info("Stopped")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+}
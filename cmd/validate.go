@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/dorianim/downtimerobot/internal/announcements"
+	"github.com/dorianim/downtimerobot/internal/crawler"
+	"github.com/dorianim/downtimerobot/internal/frontend"
+	"github.com/dorianim/downtimerobot/internal/metrics"
+	"github.com/dorianim/downtimerobot/internal/notifications"
+	"github.com/dorianim/downtimerobot/internal/schema"
+	"github.com/dorianim/downtimerobot/internal/server"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for mistakes",
+	Long: `Checks downtimerobot.yml against the keys and values downtimerobot understands.
+Reports unknown keys, values of the wrong type, invalid durations, regular expressions,
+templates and announcement dates together with their line and column.
+It is also run before run.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(validateConfig())
+	},
+}
+
+// configs are the config structs of all packages which read the config file.
+// Their keys and validate tags describe the config file for validate and schema.
+func configs() []interface{} {
+	return []interface{}{
+		crawler.EmptyConfig(),
+		notifications.EmptyConfig(),
+		announcements.EmptyConfig(),
+		frontend.EmptyConfig(),
+		server.EmptyConfig(),
+		metrics.EmptyConfig(),
+	}
+}
+
+// validateConfig logs all problems of the config file and fails if one of them is an error
+func validateConfig() error {
+	file := viper.ConfigFileUsed()
+	if len(file) == 0 {
+		log.Warn("No config file found, nothing to validate")
+		return nil
+	}
+
+	problems, err := schema.ValidateFile(file, configs()...)
+	if err != nil {
+		return err
+	}
+
+	errors := 0
+	for _, problem := range problems {
+		entry := log.WithFields(log.Fields{
+			"file":   file,
+			"line":   problem.Line,
+			"column": problem.Column,
+			"key":    problem.Path,
+		})
+		if problem.Warning {
+			entry.Warn(problem.Message)
+		} else {
+			entry.Error(problem.Message)
+			errors++
+		}
+	}
+
+	if errors > 0 {
+		return fmt.Errorf("Found %d errors in %s", errors, file)
+	}
+	log.WithFields(log.Fields{
+		"file": file,
+	}).Info("Config file is valid")
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+}
@@ -13,18 +13,22 @@ type config struct {
 type announcementsConfig struct {
 	ExportDays    int               `json:"exportDays"`
 	Announcements []rawAnnouncement `json:"announcements"`
+	// MaintenanceWindows exclude the checks of the affected services from the uptime
+	MaintenanceWindows []rawMaintenanceWindow `json:"maintenanceWindows"`
 }
 
 type rawAnnouncement struct {
-	Type       string `json:"type"`
+	Type       string `json:"type" validate:"oneof=Information Warning Alert"`
 	Title      string `json:"title"`
 	Content    string `json:"content"`
-	TimeString string `json:"timeString"`
+	TimeString string `json:"timeString" validate:"datetime"`
 }
 
 type Announcements struct {
 	ExportedDays  int            `json:"exportedDays"`
 	Announcements []Announcement `json:"announcements"`
+	// Maintenance are the active and upcoming maintenance windows
+	Maintenance []MaintenanceWindow `json:"maintenance"`
 }
 
 type Announcement struct {
@@ -32,6 +36,7 @@ type Announcement struct {
 	Type       AnnouncementType `json:"type"`
 	Content    string           `json:"content"`
 	TimeString string           `json:"timeString"`
+	Timestamp  int64            `json:"timestamp"`
 }
 
 func Generate() (*Announcements, error) {
@@ -45,6 +50,12 @@ func Generate() (*Announcements, error) {
 		return nil, err
 	}
 
+	maintenanceWindows, err := parseMaintenanceWindows(config.Announcements.MaintenanceWindows)
+	if err != nil {
+		return nil, err
+	}
+	announcements.Maintenance = getUpcomingMaintenanceWindows(maintenanceWindows, time.Now().Unix())
+
 	return announcements, nil
 }
 
@@ -54,8 +65,9 @@ func calculateTimestamps(config announcementsConfig) (*Announcements, error) {
 	announcements := make([]Announcement, 0)
 	now := time.Now()
 
-	for i, rawAnnouncement := range rawAnnouncements {
-		time, err := time.Parse("2006-01-02 15:04", rawAnnouncement.TimeString)
+	for _, rawAnnouncement := range rawAnnouncements {
+		// local like the maintenance windows, the timestamp is compared to the times of incidents
+		time, err := time.ParseInLocation("2006-01-02 15:04", rawAnnouncement.TimeString, time.Local)
 		if err != nil {
 			return nil, err
 		}
@@ -64,18 +76,25 @@ func calculateTimestamps(config announcementsConfig) (*Announcements, error) {
 			continue
 		}
 
-		announcements = append(announcements, Announcement{})
-		announcements[i].Title = rawAnnouncement.Title
-		if err := announcements[i].Type.UnmarshalJSON([]byte("\"" + rawAnnouncement.Type + "\"")); err != nil {
+		announcement := Announcement{}
+		announcement.Title = rawAnnouncement.Title
+		if err := announcement.Type.UnmarshalJSON([]byte("\"" + rawAnnouncement.Type + "\"")); err != nil {
 			return nil, err
 		}
-		announcements[i].Content = rawAnnouncement.Content
-		announcements[i].TimeString = time.Format("January 02, 2006, 15:04")
+		announcement.Content = rawAnnouncement.Content
+		announcement.TimeString = time.Format("January 02, 2006, 15:04")
+		announcement.Timestamp = time.Unix()
+		announcements = append(announcements, announcement)
 	}
 
 	return &Announcements{Announcements: announcements, ExportedDays: config.ExportDays}, nil
 }
 
+// EmptyConfig returns the announcement and maintenance window config for the schema
+func EmptyConfig() interface{} {
+	return &config{}
+}
+
 func loadConfig() (*config, error) {
 	conf := &config{}
 	if err := viper.Unmarshal(conf); err != nil {
@@ -0,0 +1,25 @@
+package announcements
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculateTimestampsUsesLocalTime(t *testing.T) {
+	local := time.Local
+	time.Local = time.FixedZone("UTC+2", 2*60*60)
+	defer func() { time.Local = local }()
+
+	announcements, err := calculateTimestamps(announcementsConfig{
+		ExportDays:    100000,
+		Announcements: []rawAnnouncement{{Type: "Information", TimeString: "2026-01-01 12:00"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC).Unix()
+	if announcements.Announcements[0].Timestamp != expected {
+		t.Errorf("expected timestamp %d, got %d", expected, announcements.Announcements[0].Timestamp)
+	}
+}
@@ -0,0 +1,107 @@
+package announcements
+
+import (
+	"sort"
+	"time"
+)
+
+type rawMaintenanceWindow struct {
+	Title   string `json:"title"`
+	Content string `json:"content"`
+	Start   string `json:"start" validate:"datetime"`
+	End     string `json:"end" validate:"datetime"`
+	// Services are the ids, names or hosts of the affected services, all services are affected if it is empty
+	Services []string `json:"services"`
+}
+
+// MaintenanceWindow is a scheduled period in which checks of the affected services are recorded as maintenance
+type MaintenanceWindow struct {
+	Title           string   `json:"title"`
+	Content         string   `json:"content"`
+	Start           int64    `json:"start"`
+	End             int64    `json:"end"`
+	StartTimeString string   `json:"startTimeString"`
+	EndTimeString   string   `json:"endTimeString"`
+	Active          bool     `json:"active"`
+	Services        []string `json:"services"`
+}
+
+// LoadMaintenanceWindows returns all configured maintenance windows
+func LoadMaintenanceWindows() ([]MaintenanceWindow, error) {
+	conf, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return parseMaintenanceWindows(conf.Announcements.MaintenanceWindows)
+}
+
+func parseMaintenanceWindows(rawWindows []rawMaintenanceWindow) ([]MaintenanceWindow, error) {
+	now := time.Now().Unix()
+	windows := make([]MaintenanceWindow, 0)
+	for _, rawWindow := range rawWindows {
+		start, err := time.ParseInLocation("2006-01-02 15:04", rawWindow.Start, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		end, err := time.ParseInLocation("2006-01-02 15:04", rawWindow.End, time.Local)
+		if err != nil {
+			return nil, err
+		}
+
+		window := MaintenanceWindow{}
+		window.Title = rawWindow.Title
+		window.Content = rawWindow.Content
+		window.Start = start.Unix()
+		window.End = end.Unix()
+		window.StartTimeString = start.Format("January 02, 2006, 15:04")
+		window.EndTimeString = end.Format("January 02, 2006, 15:04")
+		window.Active = window.IsActive(now)
+		window.Services = rawWindow.Services
+		if window.Services == nil {
+			window.Services = make([]string, 0)
+		}
+		windows = append(windows, window)
+	}
+	return windows, nil
+}
+
+// GetActiveMaintenanceWindow returns the window which affects the service at timestamp or nil
+func GetActiveMaintenanceWindow(windows []MaintenanceWindow, id string, name string, host string, timestamp int64) *MaintenanceWindow {
+	for i := range windows {
+		if windows[i].IsActive(timestamp) && windows[i].Affects(id, name, host) {
+			return &windows[i]
+		}
+	}
+	return nil
+}
+
+func (window MaintenanceWindow) IsActive(timestamp int64) bool {
+	return timestamp >= window.Start && timestamp < window.End
+}
+
+// Affects returns true if the service is listed by its id, name or host or no services are listed at all
+func (window MaintenanceWindow) Affects(id string, name string, host string) bool {
+	if len(window.Services) == 0 {
+		return true
+	}
+	for _, service := range window.Services {
+		if service == id || service == name || service == host {
+			return true
+		}
+	}
+	return false
+}
+
+// getUpcomingMaintenanceWindows returns the active and future windows, the next one comes first
+func getUpcomingMaintenanceWindows(windows []MaintenanceWindow, now int64) []MaintenanceWindow {
+	result := make([]MaintenanceWindow, 0)
+	for _, window := range windows {
+		if window.End > now {
+			result = append(result, window)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Start < result[j].Start
+	})
+	return result
+}
@@ -0,0 +1,165 @@
+package crawler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// jsonAssertion compares the value at Path in a JSON document to Value
+type jsonAssertion struct {
+	// Path is a dot separated path like "db.status" or "checks[0].state"
+	Path string `json:"path"`
+	// Operator is one of eq, ne, lt, le, gt, ge, contains and exists, it defaults to eq
+	Operator string      `json:"operator" validate:"oneof=eq ne lt le gt ge contains exists,ignorecase"`
+	Value    interface{} `json:"value"`
+	// Degraded marks the service as degraded instead of down if the assertion fails
+	Degraded bool `json:"degraded"`
+}
+
+const (
+	jsonAssertionFailed   = 610
+	jsonAssertionDegraded = 612
+)
+
+// checkJSONAssertions evaluates all assertions against body and returns an error describing the first one that failed.
+// Failed assertions which only degrade the service are reported if no other assertion failed.
+func checkJSONAssertions(assertions []jsonAssertion, body []byte) (int, error) {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return jsonAssertionFailed, fmt.Errorf("Response is not valid JSON: %s", err.Error())
+	}
+
+	var degradedErr error
+	for _, assertion := range assertions {
+		err := assertion.check(document)
+		if err != nil && !assertion.Degraded {
+			return jsonAssertionFailed, err
+		} else if err != nil && degradedErr == nil {
+			degradedErr = err
+		}
+	}
+
+	if degradedErr != nil {
+		return jsonAssertionDegraded, degradedErr
+	}
+	return 0, nil
+}
+
+func (assertion jsonAssertion) check(document interface{}) error {
+	actual, found := lookupJSONPath(document, assertion.Path)
+	operator := assertion.getOperator()
+	if !found {
+		return fmt.Errorf("Assertion %s failed: path does not exist", assertion)
+	} else if operator == "exists" {
+		return nil
+	}
+
+	ok, err := compareJSONValues(operator, actual, assertion.Value)
+	if err != nil {
+		return fmt.Errorf("Assertion %s failed: %s", assertion, err.Error())
+	}
+	if !ok {
+		return fmt.Errorf("Assertion %s failed: got %v", assertion, actual)
+	}
+	return nil
+}
+
+func (assertion jsonAssertion) getOperator() string {
+	if len(assertion.Operator) == 0 {
+		return "eq"
+	}
+	return strings.ToLower(assertion.Operator)
+}
+
+func (assertion jsonAssertion) String() string {
+	if assertion.getOperator() == "exists" {
+		return fmt.Sprintf("%q exists", assertion.Path)
+	}
+	return fmt.Sprintf("%q %s %v", assertion.Path, assertion.getOperator(), assertion.Value)
+}
+
+// lookupJSONPath walks a decoded JSON document along path
+func lookupJSONPath(document interface{}, path string) (interface{}, bool) {
+	path = strings.NewReplacer("[", ".", "]", "").Replace(path)
+	current := document
+	for _, key := range strings.Split(path, ".") {
+		if len(key) == 0 {
+			continue
+		}
+
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func compareJSONValues(operator string, actual interface{}, expected interface{}) (bool, error) {
+	switch operator {
+	case "eq":
+		return jsonValuesEqual(actual, expected), nil
+	case "ne":
+		return !jsonValuesEqual(actual, expected), nil
+	case "contains":
+		return strings.Contains(fmt.Sprint(actual), fmt.Sprint(expected)), nil
+	case "lt", "le", "gt", "ge":
+		actualNumber, actualOk := toFloat(actual)
+		expectedNumber, expectedOk := toFloat(expected)
+		if !actualOk || !expectedOk {
+			return false, fmt.Errorf("cannot compare %v and %v as numbers", actual, expected)
+		}
+		switch operator {
+		case "lt":
+			return actualNumber < expectedNumber, nil
+		case "le":
+			return actualNumber <= expectedNumber, nil
+		case "gt":
+			return actualNumber > expectedNumber, nil
+		default:
+			return actualNumber >= expectedNumber, nil
+		}
+	}
+	return false, fmt.Errorf("unknown operator %s", operator)
+}
+
+func jsonValuesEqual(actual interface{}, expected interface{}) bool {
+	actualNumber, actualOk := toFloat(actual)
+	expectedNumber, expectedOk := toFloat(expected)
+	if actualOk && expectedOk {
+		return actualNumber == expectedNumber
+	}
+	return fmt.Sprint(actual) == fmt.Sprint(expected)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,95 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckJSONAssertions(t *testing.T) {
+	body := `{"status": "ok", "db": {"latency": 12.5, "replicas": 3}, "checks": [{"name": "disk", "tags": [["a", "b"], ["c"]]}], "version": "1.10"}`
+
+	tests := []struct {
+		name       string
+		body       string
+		assertions []jsonAssertion
+		statusCode int
+		message    string
+	}{
+		{"equal string", body, []jsonAssertion{{Path: "status", Value: "ok"}}, 0, ""},
+		{"nested number", body, []jsonAssertion{{Path: "db.latency", Operator: "lt", Value: 20}}, 0, ""},
+		{"number from a string", body, []jsonAssertion{{Path: "db.replicas", Operator: "GE", Value: "3"}}, 0, ""},
+		{"nested arrays", body, []jsonAssertion{{Path: "checks[0].tags[1][0]", Value: "c"}}, 0, ""},
+		{"exists", body, []jsonAssertion{{Path: "checks[0].name", Operator: "exists"}}, 0, ""},
+		{"contains", body, []jsonAssertion{{Path: "version", Operator: "contains", Value: "1."}}, 0, ""},
+		{"not equal", body, []jsonAssertion{{Path: "status", Value: "degraded"}}, jsonAssertionFailed, `"status" eq degraded failed: got ok`},
+		{"missing path", body, []jsonAssertion{{Path: "db.primary", Operator: "exists"}}, jsonAssertionFailed, "path does not exist"},
+		{"index out of range", body, []jsonAssertion{{Path: "checks[1].name"}}, jsonAssertionFailed, "path does not exist"},
+		{"path into a value", body, []jsonAssertion{{Path: "status.code"}}, jsonAssertionFailed, "path does not exist"},
+		{"type mismatch", body, []jsonAssertion{{Path: "status", Operator: "gt", Value: 1}}, jsonAssertionFailed, "cannot compare ok and 1 as numbers"},
+		{"object compared as number", body, []jsonAssertion{{Path: "db", Operator: "lt", Value: 1}}, jsonAssertionFailed, "as numbers"},
+		{"invalid json", `{"status": "ok"`, []jsonAssertion{{Path: "status", Value: "ok"}}, jsonAssertionFailed, "Response is not valid JSON"},
+		{"html instead of json", "<html></html>", []jsonAssertion{{Path: "status", Value: "ok"}}, jsonAssertionFailed, "Response is not valid JSON"},
+		{"degraded", body, []jsonAssertion{{Path: "db.latency", Operator: "lt", Value: 10, Degraded: true}}, jsonAssertionDegraded, `"db.latency" lt 10 failed`},
+		{"failure beats degradation", body, []jsonAssertion{
+			{Path: "db.latency", Operator: "lt", Value: 10, Degraded: true},
+			{Path: "status", Value: "degraded"},
+		}, jsonAssertionFailed, `"status" eq degraded`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statusCode, err := checkJSONAssertions(test.assertions, []byte(test.body))
+			if statusCode != test.statusCode {
+				t.Errorf("expected status code %d, got %d: %v", test.statusCode, statusCode, err)
+			}
+			if len(test.message) == 0 && err != nil {
+				t.Errorf("expected no error, got %s", err.Error())
+			} else if len(test.message) > 0 && (err == nil || !strings.Contains(err.Error(), test.message)) {
+				t.Errorf("expected an error containing %q, got %v", test.message, err)
+			}
+		})
+	}
+}
+
+func TestHTTPSServiceAssertions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(150 * time.Millisecond)
+		}
+		w.Write([]byte(`{"status": "ok"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name            string
+		path            string
+		value           string
+		maxResponseTime time.Duration
+		statusCode      int
+	}{
+		{"assertion holds", "/", "ok", 0, http.StatusOK},
+		{"assertion fails", "/", "down", 0, jsonAssertionFailed},
+		{"fast enough", "/", "ok", time.Second, http.StatusOK},
+		{"too slow", "/slow", "ok", 50 * time.Millisecond, responseTooSlow},
+		{"failed assertion is not only slow", "/slow", "down", 50 * time.Millisecond, jsonAssertionFailed},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &httpsService{
+				genericService: genericService{MaxResponseTime: test.maxResponseTime},
+				URL:            server.URL + test.path,
+				Assertions:     []jsonAssertion{{Path: "status", Value: test.value}},
+			}
+
+			dataPoint := crawlService(context.Background(), service, crawlerConfig{})
+			if dataPoint.GetStatusCode() != test.statusCode {
+				t.Errorf("expected status code %d, got %d: %s", test.statusCode, dataPoint.GetStatusCode(), dataPoint.GetStatusMessage())
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// CertificateService is implemented by services which present a TLS certificate
+type CertificateService interface {
+	// GetCertificate returns the first expiring certificate of the chain presented in the last crawl
+	GetCertificate() (CertificateInfo, bool)
+}
+
+// CertificateInfo describes the certificate of a chain which expires first.
+// This is usually the leaf but may be an intermediate certificate.
+type CertificateInfo struct {
+	Subject  string
+	Issuer   string
+	NotAfter time.Time
+}
+
+const (
+	certificateExpiring = 606
+	certificateInvalid  = 607
+)
+
+// requestErrorStatus maps an error returned by fetch to a status code and message
+func requestErrorStatus(err error) (int, string) {
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var authorityErr x509.UnknownAuthorityError
+	if errors.As(err, &hostnameErr) || errors.As(err, &invalidErr) || errors.As(err, &authorityErr) {
+		return certificateInvalid, err.Error()
+	}
+	return httpsRequestError, err.Error()
+}
+
+// checkCertificate inspects the certificates presented in resp. It returns false together with
+// a status code and message if they expire within the threshold. Invalid certificates are
+// already rejected by the client and reported by requestErrorStatus.
+func (service *httpsService) checkCertificate(resp *http.Response) (bool, int, string) {
+	service.setCertificate(nil)
+	if resp.TLS == nil || len(resp.TLS.PeerCertificates) == 0 {
+		return true, 0, ""
+	}
+
+	// the verified chain also contains the root of the trust store, it is missing if verification is skipped
+	chain := resp.TLS.PeerCertificates
+	if len(resp.TLS.VerifiedChains) > 0 {
+		chain = resp.TLS.VerifiedChains[0]
+	}
+	info := getFirstExpiringCertificate(chain)
+	service.setCertificate(&info)
+
+	daysLeft := info.GetDaysLeft()
+	if service.CertificateExpiryThreshold > 0 && daysLeft < service.CertificateExpiryThreshold {
+		return false, certificateExpiring, fmt.Sprintf("Certificate %s issued by %s expires in %d days", info.Subject, info.Issuer, daysLeft)
+	}
+	return true, 0, ""
+}
+
+func getFirstExpiringCertificate(chain []*x509.Certificate) CertificateInfo {
+	first := chain[0]
+	for _, certificate := range chain[1:] {
+		if certificate.NotAfter.Before(first.NotAfter) {
+			first = certificate
+		}
+	}
+	return CertificateInfo{Subject: getName(first.Subject), Issuer: getName(first.Issuer), NotAfter: first.NotAfter}
+}
+
+func getName(name pkix.Name) string {
+	if len(name.CommonName) > 0 {
+		return name.CommonName
+	}
+	return name.String()
+}
+
+// GetDaysLeft returns the number of full days until the certificate expires
+func (info CertificateInfo) GetDaysLeft() int {
+	return int(time.Until(info.NotAfter).Hours() / 24)
+}
+
+func (service *httpsService) GetCertificate() (CertificateInfo, bool) {
+	service.certificateMutex.RLock()
+	defer service.certificateMutex.RUnlock()
+	if service.certificate == nil {
+		return CertificateInfo{}, false
+	}
+	return *service.certificate, true
+}
+
+// setCertificate is guarded because the certificate is read while the service is crawled in serve mode
+func (service *httpsService) setCertificate(info *CertificateInfo) {
+	service.certificateMutex.Lock()
+	defer service.certificateMutex.Unlock()
+	service.certificate = info
+}
@@ -0,0 +1,49 @@
+package crawler
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckCertificateReportsTheFirstExpiringCertificate(t *testing.T) {
+	now := time.Now()
+	leaf := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "example.com"},
+		Issuer:   pkix.Name{CommonName: "Intermediate CA"},
+		NotAfter: now.AddDate(0, 0, 60),
+	}
+	intermediate := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "Intermediate CA"},
+		Issuer:   pkix.Name{Organization: []string{"Root"}},
+		NotAfter: now.AddDate(0, 0, 10).Add(time.Hour),
+	}
+	resp := &http.Response{TLS: &tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf, intermediate}}}
+
+	service := &httpsService{CertificateExpiryThreshold: 14}
+	ok, statusCode, statusMessage := service.checkCertificate(resp)
+	if ok || statusCode != certificateExpiring {
+		t.Fatalf("expected status code %d, got %d", certificateExpiring, statusCode)
+	}
+	if !strings.Contains(statusMessage, "Intermediate CA issued by O=Root expires in 10 days") {
+		t.Errorf("expected the intermediate certificate in the message, got %q", statusMessage)
+	}
+
+	certificate, ok := service.GetCertificate()
+	if !ok {
+		t.Fatal("expected the certificate of the last crawl")
+	}
+	expected := CertificateInfo{Subject: "Intermediate CA", Issuer: "O=Root", NotAfter: intermediate.NotAfter}
+	if certificate != expected || certificate.GetDaysLeft() != 10 {
+		t.Errorf("expected %+v with 10 days left, got %+v with %d days left", expected, certificate, certificate.GetDaysLeft())
+	}
+
+	service.CertificateExpiryThreshold = 7
+	if ok, statusCode, _ := service.checkCertificate(resp); !ok {
+		t.Errorf("expected no degradation above the threshold, got status code %d", statusCode)
+	}
+}
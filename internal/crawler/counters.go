@@ -0,0 +1,81 @@
+package crawler
+
+import (
+	"sync"
+)
+
+// CheckDurationBuckets are the upper bounds of the check duration histogram in seconds
+var CheckDurationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// CrawlCounters are counted in-process since the start. Unlike values derived from the historic data,
+// they never decrease when data points are downsampled or removed by the retention.
+type CrawlCounters struct {
+	// Checks is the number of crawls which checked the service, disabled services and maintenance are not checked
+	Checks int
+	// Failures is the number of checks in which the service was down
+	Failures int
+	// Errors is the number of crawls which did not check the service before the deadline
+	Errors int
+	// LastError is set if the last crawl did not check the service before the deadline
+	LastError bool
+
+	// DurationBuckets counts the checks with a response time up to the bound of CheckDurationBuckets
+	DurationBuckets []int
+	DurationCount   int
+	DurationSum     float64
+}
+
+var (
+	crawlCounters      = make(map[string]*CrawlCounters)
+	crawlCountersMutex sync.Mutex
+)
+
+// GetCrawlCounters returns the counters of the service with the id, if it was crawled since the start
+func GetCrawlCounters(id string) (CrawlCounters, bool) {
+	crawlCountersMutex.Lock()
+	defer crawlCountersMutex.Unlock()
+
+	counters, ok := crawlCounters[id]
+	if !ok {
+		return CrawlCounters{}, false
+	}
+	result := *counters
+	result.DurationBuckets = append([]int{}, counters.DurationBuckets...)
+	return result, true
+}
+
+// countCrawl adds the result of a crawl to the counters of the service, dataPoint is nil if the crawl missed the deadline
+func countCrawl(service Service, dataPoint HistoricDataPoint) {
+	crawlCountersMutex.Lock()
+	defer crawlCountersMutex.Unlock()
+
+	counters, ok := crawlCounters[service.GetID()]
+	if !ok {
+		counters = &CrawlCounters{DurationBuckets: make([]int, len(CheckDurationBuckets))}
+		crawlCounters[service.GetID()] = counters
+	}
+
+	counters.LastError = dataPoint == nil
+	if dataPoint == nil {
+		counters.Errors++
+		return
+	}
+	if dataPoint.IsDisabled() || dataPoint.IsMaintenance() {
+		return
+	}
+
+	counters.Checks++
+	if !dataPoint.IsUp() {
+		counters.Failures++
+	}
+	if dataPoint.GetResponseTime() > 0 {
+		seconds := float64(dataPoint.GetResponseTime()) / 1000
+		for i, bucket := range CheckDurationBuckets {
+			if seconds <= bucket {
+				counters.DurationBuckets[i]++
+			}
+		}
+		counters.DurationCount++
+		counters.DurationSum += seconds
+	}
+}
@@ -0,0 +1,38 @@
+package crawler
+
+import (
+	"testing"
+)
+
+func TestCountCrawl(t *testing.T) {
+	service := &portService{genericService: genericService{ID: "count-crawl"}}
+	for _, dataPoint := range []HistoricDataPoint{
+		service.newDataPoint(rawHistoricDataPoint{0, portOpen, 40, "", 1, nil}),
+		service.newDataPoint(rawHistoricDataPoint{0, portConnectionError, 3000, "", 1, nil}),
+		service.newDataPoint(rawHistoricDataPoint{0, maintenanceStatusCode, -1, "", 1, nil}),
+		nil,
+	} {
+		countCrawl(service, dataPoint)
+	}
+
+	counters, ok := GetCrawlCounters("count-crawl")
+	if !ok {
+		t.Fatal("expected counters of the crawled service")
+	}
+	if counters.Checks != 2 || counters.Failures != 1 || counters.Errors != 1 || !counters.LastError {
+		t.Errorf("expected 2 checks, 1 failure and 1 error in the last crawl, got %+v", counters)
+	}
+	if counters.DurationCount != 2 || counters.DurationBuckets[0] != 1 || counters.DurationBuckets[len(CheckDurationBuckets)-1] != 2 {
+		t.Errorf("expected one fast and one slow check, got %+v", counters)
+	}
+
+	// the historic data may shrink, the counters do not
+	countCrawl(service, service.newDataPoint(rawHistoricDataPoint{0, portOpen, 40, "", 1, nil}))
+	if next, _ := GetCrawlCounters("count-crawl"); next.Checks != 3 || next.Failures != 1 || next.LastError {
+		t.Errorf("expected 3 checks without a new failure, got %+v", next)
+	}
+
+	if _, ok := GetCrawlCounters("never-crawled"); ok {
+		t.Error("expected no counters of a service which was never crawled")
+	}
+}
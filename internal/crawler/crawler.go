@@ -1,57 +1,106 @@
 package crawler
 
 import (
-	"io/ioutil"
-	"os"
+	"context"
+	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
-	"github.com/goccy/go-json"
+	"github.com/dorianim/downtimerobot/internal/announcements"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 )
 
 // Service describes a service wich is monitored by downtimerobot
 type Service interface {
-	crawl() HistoricDataPoint
+	crawl(ctx context.Context) rawHistoricDataPoint
+	newDataPoint(rawHistoricDataPoint) HistoricDataPoint
 	setHistoricData([]rawHistoricDataPoint)
-
+	appendHistoricData(HistoricDataPoint)
+	getRetryOptions() retryOptions
+	getInterval() time.Duration
+	getMaxResponseTime() time.Duration
+	getConfiguredID() string
+	setID(string)
+
+	GetID() string
 	GetHost() string
 	GetName() string
 	GetType() string
 	IsDisabled() bool
 	IsUp() bool
+	IsDegraded() bool
+	GetStateOptions() StateOptions
 	GetHistoricData() []HistoricDataPoint
 }
 
 type genericService struct {
 	Service
-	Name         string `json:"name"`
-	Host         string `json:"host"`
-	Disabled     bool   `json:"disabled"`
-	historicData []HistoricDataPoint
+	StateOptions `mapstructure:",squash"`
+	retryOptions `mapstructure:",squash"`
+	// ID identifies the service in the historic data and names its data file, the default is derived from its name
+	ID       string `json:"id" validate:"slug,notoneof=servicelist announcementlist incidents"`
+	Name     string `json:"name"`
+	Host     string `json:"host"`
+	Disabled bool   `json:"disabled"`
+	// Interval is the time between two crawls in serve mode
+	Interval time.Duration `json:"interval"`
+	// MaxResponseTime marks the service as degraded if it responds slower
+	MaxResponseTime time.Duration `json:"maxResponseTime"`
+	historicData    []HistoricDataPoint
+	// id is assigned by assignIDs
+	id string
 }
 
-// HistoricDataPoint is the status of a service at a certain point of time
+// HistoricDataPoint is the status of a service at a certain point of time.
+// A degraded data point is also up, the service responded but not as expected.
 type HistoricDataPoint interface {
 	IsUp() bool
+	IsDegraded() bool
 	IsDisabled() bool
+	IsMaintenance() bool
 	GetStatusCode() int
 	GetStatusMessage() string
 	GetResponseTime() int64
 	GetTimestamp() int64
+	GetAttempts() int
+	// GetRollup returns nil for data points of a single crawl
+	GetRollup() *Rollup
 
 	getRawDataPoint() rawHistoricDataPoint
 }
 
 type config struct {
-	Services struct {
+	Crawler   crawlerConfig
+	Storage   StorageConfig
+	Retention RetentionOptions
+	Services  struct {
 		HTTPS   []*httpsService
-		Ping    []*PingService
-		Port    []*PortService
+		Ping    []*pingService
+		Port    []*portService
 		Pattern []*patternService
+		DNS     []*dnsService
 	}
 }
 
+type crawlerConfig struct {
+	// Workers is the number of services which are crawled concurrently
+	Workers int `json:"workers"`
+	// Timeout is the deadline for the whole crawl
+	Timeout time.Duration `json:"timeout"`
+	// ServiceTimeout is the deadline for a single check of a service
+	ServiceTimeout time.Duration `json:"serviceTimeout"`
+	// retryOptions are the defaults for all services
+	retryOptions `mapstructure:",squash"`
+	// Interval is the default time between two crawls of a service in serve mode
+	Interval time.Duration `json:"interval"`
+
+	maintenanceWindows []announcements.MaintenanceWindow
+}
+
 type rawHistoricDataPoint struct {
 	Timestamp    int64 `json:"t"`
 	StatusCode   int   `json:"c"`
@@ -60,39 +109,93 @@ type rawHistoricDataPoint struct {
 	// StatusMessage is only used for non-standard errors
 	// it may be empty in many cases. Use Service::GetStatus
 	StatusMessage string `json:"m"`
+
+	// Attempts is the number of checks needed in the crawl, it is missing in old data points
+	Attempts int `json:"a,omitempty"`
+
+	// Rollup is set if the data point aggregates older data points, see RetentionOptions
+	Rollup *Rollup `json:"o,omitempty"`
 }
 
 type rawHistoricData map[string][]rawHistoricDataPoint
 
 const historicDataFile = "./historicData.json"
 
+// maintenanceStatusCode is recorded instead of a check while a maintenance window is active
+const maintenanceStatusCode = -2
+
+// responseTooSlow is recorded instead of a successful check which exceeded the MaxResponseTime of the service
+const responseTooSlow = 611
+
+const (
+	defaultCrawlerWorkers        = 8
+	defaultCrawlerTimeout        = 5 * time.Minute
+	defaultCrawlerServiceTimeout = 30 * time.Second
+	defaultCrawlerInterval       = time.Minute
+)
+
 // CrawlServices crawls all configured services and adds the result to their historic data
 func CrawlServices() ([]Service, error) {
-	services, err := LoadServices()
+	conf, storage, services, err := openServices()
 	if err != nil {
 		return nil, err
 	}
+	defer storage.close()
 
-	crawlServices(services)
-	if err := storeHistoricData(services); err != nil {
+	crawlServices(services, conf.Crawler)
+	applyRetention(services, conf.Retention)
+	if err := storeHistoricData(storage, services); err != nil {
 		return nil, err
 	}
 
 	return services, err
 }
 
+// LoadServices loads the services with their historic data without crawling them
 func LoadServices() ([]Service, error) {
-	conf, err := loadConfig()
+	_, storage, services, err := openServices()
 	if err != nil {
 		return nil, err
 	}
+	storage.close()
+	return services, nil
+}
 
-	historicData, err := loadHistoricData()
+// openServices loads the config and the services with their historic data.
+// The storage has to be closed by the caller.
+func openServices() (*config, historicDataStorage, []Service, error) {
+	conf, err := loadConfig()
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
+	}
+
+	conf.Crawler.maintenanceWindows, err = announcements.LoadMaintenanceWindows()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	storage, err := openStorage(conf.Storage)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	historicData, err := storage.load()
+	if err != nil {
+		storage.close()
+		return nil, nil, nil, err
+	}
+
+	services, err := loadServices(conf, historicData)
+	if err != nil {
+		storage.close()
+		return nil, nil, nil, err
 	}
+	return conf, storage, services, nil
+}
 
-	return loadServices(conf, historicData), nil
+// EmptyConfig returns the crawler, storage, retention and service config for the schema
+func EmptyConfig() interface{} {
+	return &config{}
 }
 
 func loadConfig() (*config, error) {
@@ -103,25 +206,98 @@ func loadConfig() (*config, error) {
 	return conf, nil
 }
 
-func loadServices(conf *config, historicData rawHistoricData) []Service {
+func loadServices(conf *config, historicData rawHistoricData) ([]Service, error) {
 	result := make([]Service, 0)
 
 	serviceTypes := reflect.ValueOf(conf.Services)
 	for i := 0; i < serviceTypes.NumField(); i++ {
 		field := serviceTypes.Field(i)
 		for j := 0; j < field.Len(); j++ {
-			service := field.Index(j).Interface().(Service)
-			injectHistoricDataIntoService(historicData, service)
-			result = append(result, service)
+			result = append(result, field.Index(j).Interface().(Service))
 		}
 	}
 
-	return result
+	if err := assignIDs(result); err != nil {
+		return nil, err
+	}
+	for _, service := range result {
+		injectHistoricDataIntoService(historicData, service)
+	}
+	return result, nil
 }
 
-func crawlServices(services []Service) {
+// assignIDs checks the configured ids and assigns unique ids to the services without one.
+// Configured ids have to be unique. A default id which is already taken is qualified with the type of the service.
+func assignIDs(services []Service) error {
+	ids := make(map[string]bool)
 	for _, service := range services {
-		crawlService(service)
+		id := service.getConfiguredID()
+		if len(id) == 0 {
+			continue
+		}
+		if err := checkID(id); err != nil {
+			return err
+		}
+		if ids[id] {
+			return fmt.Errorf("Duplicate service id %s, set a unique id for the service %s", id, service.GetName())
+		}
+		ids[id] = true
+	}
+
+	for _, service := range services {
+		if len(service.getConfiguredID()) > 0 {
+			continue
+		}
+
+		id := service.GetID()
+		if ids[id] || isReservedID(id) || len(id) == 0 {
+			qualified := strings.Trim(service.GetType()+"-"+id, "-")
+			id = qualified
+			for n := 2; ids[id]; n++ {
+				id = fmt.Sprintf("%s-%d", qualified, n)
+			}
+			log.WithFields(log.Fields{
+				"service": service.GetName(),
+				"id":      id,
+			}).Warn("Default id of the service is already taken, set an id to keep it stable")
+		}
+		ids[id] = true
+		service.setID(id)
+	}
+	return nil
+}
+
+// crawlServices crawls the services concurrently and logs the results in the order of the services
+func crawlServices(services []Service, conf crawlerConfig) {
+	ctx, cancel := context.WithTimeout(context.Background(), conf.getTimeout())
+	defer cancel()
+
+	dataPoints := make([]HistoricDataPoint, len(services))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+
+	for i := 0; i < conf.getWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				dataPoints[j] = crawlService(ctx, services[j], conf)
+			}
+		}()
+	}
+
+	for i := range services {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, service := range services {
+		if dataPoints[i] != nil {
+			service.appendHistoricData(dataPoints[i])
+		}
+		logDataPoint(service, dataPoints[i])
+		countCrawl(service, dataPoints[i])
 	}
 }
 
@@ -133,61 +309,161 @@ func dataPointsToRawDataPoints(dataPoints []HistoricDataPoint) []rawHistoricData
 	return result
 }
 
-func loadHistoricData() (rawHistoricData, error) {
-	jsonFile, err := os.Open(historicDataFile)
-	defer jsonFile.Close()
-
-	if err != nil && os.IsNotExist(err) {
-		return rawHistoricData{}, nil
-	} else if err != nil {
-		return nil, err
+func storeHistoricData(storage historicDataStorage, services []Service) error {
+	rawData := rawHistoricData{}
+	for _, service := range services {
+		rawData[service.GetID()] = dataPointsToRawDataPoints(service.GetHistoricData())
 	}
+	return storage.store(rawData)
+}
 
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-	data := make(rawHistoricData)
-	err = json.Unmarshal(byteValue, &data)
-	return data, err
+// injectHistoricDataIntoService also migrates data which was stored by host before services had ids.
+// If several services share a host, the first one gets its data.
+func injectHistoricDataIntoService(data rawHistoricData, service Service) {
+	if serviceData, ok := data[service.GetID()]; ok {
+		service.setHistoricData(serviceData)
+	} else if serviceData, ok := data[service.GetHost()]; ok {
+		log.WithFields(log.Fields{
+			"service": service.GetHost(),
+			"id":      service.GetID(),
+		}).Info("Migrating historic data from host to id")
+		service.setHistoricData(serviceData)
+		delete(data, service.GetHost())
+	}
 }
 
-func storeHistoricData(services []Service) error {
-	rawData := rawHistoricData{}
-	for _, service := range services {
-		rawData[service.GetHost()] = dataPointsToRawDataPoints(service.GetHistoricData())
+// crawlService crawls the service unless the crawl deadline has already passed.
+// Failed checks are repeated according to the retry options of the service.
+// During a maintenance window the service is not checked at all.
+// The data point is not appended to the historic data, so services can be crawled concurrently.
+// It is nil if the crawl was canceled or its deadline passed, also while a check was running.
+func crawlService(ctx context.Context, service Service, conf crawlerConfig) HistoricDataPoint {
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	now := time.Now().Unix()
+	window := announcements.GetActiveMaintenanceWindow(conf.maintenanceWindows, service.GetID(), service.GetName(), service.GetHost(), now)
+	if window != nil && !service.IsDisabled() {
+		return service.newDataPoint(rawHistoricDataPoint{now, maintenanceStatusCode, -1, "Scheduled maintenance: " + window.Title, 1, nil})
 	}
 
-	data, _ := json.MarshalIndent(rawData, "", " ")
-	return ioutil.WriteFile(historicDataFile, data, 0644)
+	options := conf.retryOptions.merge(service.getRetryOptions())
+	var dataPoint HistoricDataPoint
+	for attempt := 1; ; attempt++ {
+		serviceCtx, cancel := context.WithTimeout(ctx, conf.getServiceTimeout())
+		rawDataPoint := service.crawl(serviceCtx)
+		cancel()
+
+		rawDataPoint.Attempts = attempt
+		dataPoint = service.newDataPoint(rawDataPoint)
+		if dataPoint.IsUp() || dataPoint.IsDisabled() || dataPoint.IsMaintenance() || attempt >= options.getAttempts() {
+			break
+		}
+
+		log.WithFields(log.Fields{
+			"service":       service.GetID(),
+			"host":          service.GetHost(),
+			"type":          service.GetType(),
+			"attempt":       attempt,
+			"statusMessage": dataPoint.GetStatusMessage(),
+		}).Debug("Check failed, retrying")
+
+		if !sleep(ctx, options.getDelay(attempt)) {
+			break
+		}
+	}
+
+	// a check interrupted by the deadline or a shutdown failed because of it, not because of the service
+	if ctx.Err() != nil {
+		return nil
+	}
+	return checkResponseTime(service, dataPoint)
 }
 
-func injectHistoricDataIntoService(data rawHistoricData, service Service) {
-	if serviceData, ok := data[service.GetHost()]; ok {
-		service.setHistoricData(serviceData)
+// checkResponseTime marks an otherwise healthy data point as degraded if the service responded too slowly
+func checkResponseTime(service Service, dataPoint HistoricDataPoint) HistoricDataPoint {
+	maxResponseTime := service.getMaxResponseTime()
+	if maxResponseTime <= 0 || !dataPoint.IsUp() || dataPoint.IsDegraded() || dataPoint.GetResponseTime() <= maxResponseTime.Milliseconds() {
+		return dataPoint
 	}
+
+	rawDataPoint := dataPoint.getRawDataPoint()
+	rawDataPoint.StatusCode = responseTooSlow
+	rawDataPoint.StatusMessage = fmt.Sprintf("Response took %d ms, the limit is %d ms", rawDataPoint.ResponseTime, maxResponseTime.Milliseconds())
+	return service.newDataPoint(rawDataPoint)
 }
 
-func crawlService(service Service) {
-	newDataPoint := service.crawl()
-	if newDataPoint.IsDisabled() {
+func logDataPoint(service Service, newDataPoint HistoricDataPoint) {
+	if newDataPoint == nil {
 		log.WithFields(log.Fields{
-			"service": service.GetHost(),
+			"service": service.GetID(),
+			"host":    service.GetHost(),
+			"type":    service.GetType(),
+		}).Warn("Service was not crawled before the deadline")
+	} else if newDataPoint.IsDisabled() {
+		log.WithFields(log.Fields{
+			"service": service.GetID(),
+			"host":    service.GetHost(),
 			"type":    service.GetType(),
 		}).Info("Service is DISABLED")
+	} else if newDataPoint.IsMaintenance() {
+		log.WithFields(log.Fields{
+			"service":       service.GetID(),
+			"host":          service.GetHost(),
+			"type":          service.GetType(),
+			"statusMessage": newDataPoint.GetStatusMessage(),
+		}).Info("Service is in MAINTENANCE")
+	} else if newDataPoint.IsDegraded() {
+		log.WithFields(log.Fields{
+			"service":       service.GetID(),
+			"host":          service.GetHost(),
+			"type":          service.GetType(),
+			"statusMessage": newDataPoint.GetStatusMessage(),
+			"statusCode":    newDataPoint.GetStatusCode(),
+		}).Warn("Service is DEGRADED")
 	} else if newDataPoint.IsUp() {
 		log.WithFields(log.Fields{
-			"service": service.GetHost(),
+			"service": service.GetID(),
+			"host":    service.GetHost(),
 			"type":    service.GetType(),
 		}).Info("Service is UP")
 	} else {
 		log.WithFields(log.Fields{
-			"service":       service.GetHost(),
+			"service":       service.GetID(),
+			"host":          service.GetHost(),
 			"type":          service.GetType(),
 			"statusMessage": newDataPoint.GetStatusMessage(),
 			"statusCode":    newDataPoint.GetStatusCode(),
+			"attempts":      newDataPoint.GetAttempts(),
 		}).Warn("Service is Down")
 	}
 }
 
 // == genericService ==
+
+// GetID returns the configured id or a slug of the name or host
+func (service *genericService) GetID() string {
+	if len(service.ID) > 0 {
+		return service.ID
+	}
+	if len(service.id) > 0 {
+		return service.id
+	}
+	if id := slugify(service.Name); len(id) > 0 {
+		return id
+	}
+	return slugify(service.Host)
+}
+
+func (service *genericService) getConfiguredID() string {
+	return service.ID
+}
+
+func (service *genericService) setID(id string) {
+	service.id = id
+}
+
 func (service *genericService) GetHost() string {
 	return service.Host
 }
@@ -200,15 +476,27 @@ func (service *genericService) IsDisabled() bool {
 	return service.Disabled
 }
 
+// IsUp returns the confirmed state, see StateOptions
 func (genericService *genericService) IsUp() bool {
-	historicData := genericService.GetHistoricData()
-	historicDataLength := len(historicData)
-	if historicDataLength > 0 {
-		return historicData[historicDataLength-1].IsUp()
+	_, current := confirmStates(genericService.GetHistoricData(), genericService.StateOptions)
+	if current != nil {
+		return current.IsUp()
 	}
 	return false
 }
 
+func (genericService *genericService) IsDegraded() bool {
+	_, current := confirmStates(genericService.GetHistoricData(), genericService.StateOptions)
+	if current != nil {
+		return current.IsDegraded()
+	}
+	return false
+}
+
+func (genericService *genericService) GetStateOptions() StateOptions {
+	return genericService.StateOptions
+}
+
 func (genericService *genericService) GetHistoricData() []HistoricDataPoint {
 	return genericService.historicData
 }
@@ -217,20 +505,82 @@ func (genericService *genericService) GetType() string {
 	return "generic"
 }
 
-func (genericService *genericService) crawl() HistoricDataPoint {
+func (genericService *genericService) crawl(ctx context.Context) rawHistoricDataPoint {
+	return rawHistoricDataPoint{}
+}
+
+func (genericService *genericService) newDataPoint(rawHistoricDataPoint) HistoricDataPoint {
 	return nil
 }
 
+func (genericService *genericService) appendHistoricData(dataPoint HistoricDataPoint) {
+	genericService.historicData = append(genericService.historicData, dataPoint)
+}
+
+func (genericService *genericService) getRetryOptions() retryOptions {
+	return genericService.retryOptions
+}
+
+func (genericService *genericService) getInterval() time.Duration {
+	return genericService.Interval
+}
+
+func (genericService *genericService) getMaxResponseTime() time.Duration {
+	return genericService.MaxResponseTime
+}
+
 func (genericService *genericService) setHistoricData([]rawHistoricDataPoint) {
 
 }
 
+// == crawlerConfig ==
+
+func (conf crawlerConfig) getWorkers() int {
+	if conf.Workers <= 0 {
+		return defaultCrawlerWorkers
+	}
+	return conf.Workers
+}
+
+func (conf crawlerConfig) getTimeout() time.Duration {
+	if conf.Timeout <= 0 {
+		return defaultCrawlerTimeout
+	}
+	return conf.Timeout
+}
+
+// getInterval returns the interval of the service or the default one
+func (conf crawlerConfig) getInterval(service Service) time.Duration {
+	if service.getInterval() > 0 {
+		return service.getInterval()
+	}
+	if conf.Interval > 0 {
+		return conf.Interval
+	}
+	return defaultCrawlerInterval
+}
+
+func (conf crawlerConfig) getServiceTimeout() time.Duration {
+	if conf.ServiceTimeout <= 0 {
+		return defaultCrawlerServiceTimeout
+	}
+	return conf.ServiceTimeout
+}
+
 // == rawHistoricDataPoint ==
 
 func (dataPoint rawHistoricDataPoint) IsDisabled() bool {
 	return dataPoint.StatusCode == -1
 }
 
+func (dataPoint rawHistoricDataPoint) IsMaintenance() bool {
+	return dataPoint.StatusCode == maintenanceStatusCode
+}
+
+func (dataPoint rawHistoricDataPoint) IsDegraded() bool {
+	return dataPoint.StatusCode == responseTooSlow
+}
+
 func (dataPoint rawHistoricDataPoint) GetResponseTime() int64 {
 	return dataPoint.ResponseTime
 }
@@ -239,6 +589,22 @@ func (dataPoint rawHistoricDataPoint) GetTimestamp() int64 {
 	return dataPoint.Timestamp
 }
 
+// GetAttempts returns the number of checks needed in the crawl
+func (dataPoint rawHistoricDataPoint) GetAttempts() int {
+	if dataPoint.Attempts <= 1 {
+		return 1
+	}
+	return dataPoint.Attempts
+}
+
+func (dataPoint rawHistoricDataPoint) IsRollup() bool {
+	return dataPoint.Rollup != nil
+}
+
+func (dataPoint rawHistoricDataPoint) GetRollup() *Rollup {
+	return dataPoint.Rollup
+}
+
 func (dataPoint rawHistoricDataPoint) getRawDataPoint() rawHistoricDataPoint {
 	return dataPoint
 }
@@ -246,3 +612,46 @@ func (dataPoint rawHistoricDataPoint) getRawDataPoint() rawHistoricDataPoint {
 func (dataPoint rawHistoricDataPoint) GetStatusCode() int {
 	return dataPoint.StatusCode
 }
+
+// == helper ==
+
+var slugInvalidCharacters = regexp.MustCompile("[^a-z0-9]+")
+
+// validID matches slugs, ids are used as file names
+var validID = regexp.MustCompile("^[a-z0-9]+(-[a-z0-9]+)*$")
+
+// reservedIDs are the names of the other data files of the frontend, see frontend.Generate
+var reservedIDs = []string{"servicelist", "announcementlist", "incidents"}
+
+func checkID(id string) error {
+	if !validID.MatchString(id) {
+		return fmt.Errorf("Invalid service id %s, use lowercase letters, digits and dashes", id)
+	}
+	if isReservedID(id) {
+		return fmt.Errorf("Invalid service id %s, it is reserved for the frontend", id)
+	}
+	return nil
+}
+
+func isReservedID(id string) bool {
+	for _, reserved := range reservedIDs {
+		if strings.EqualFold(id, reserved) {
+			return true
+		}
+	}
+	return false
+}
+
+// slugify lowercases the text and replaces everything but letters and digits with dashes
+func slugify(text string) string {
+	return strings.Trim(slugInvalidCharacters.ReplaceAllString(strings.ToLower(text), "-"), "-")
+}
+
+// deadline returns the earlier one of now + timeout and the deadline of ctx
+func deadline(ctx context.Context, timeout time.Duration) time.Time {
+	result := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(result) {
+		return ctxDeadline
+	}
+	return result
+}
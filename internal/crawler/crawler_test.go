@@ -0,0 +1,67 @@
+package crawler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAssignIDs(t *testing.T) {
+	https := &httpsService{genericService: genericService{Name: "API"}}
+	port := &portService{genericService: genericService{Name: "API"}}
+	explicit := &pingService{genericService: genericService{Name: "Ping", ID: "port-api"}}
+	reserved := &httpsService{genericService: genericService{Name: "Incidents"}}
+
+	if err := assignIDs([]Service{https, port, explicit, reserved}); err != nil {
+		t.Fatal(err)
+	}
+
+	for service, expected := range map[Service]string{https: "api", port: "port-api-2", explicit: "port-api", reserved: "https-incidents"} {
+		if service.GetID() != expected {
+			t.Errorf("expected id %s for %s, got %s", expected, service.GetName(), service.GetID())
+		}
+	}
+}
+
+func TestAssignIDsRejectsInvalidIDs(t *testing.T) {
+	for _, services := range [][]Service{
+		{&httpsService{genericService: genericService{ID: "api"}}, &portService{genericService: genericService{ID: "api"}}},
+		{&httpsService{genericService: genericService{ID: "../api"}}},
+		{&httpsService{genericService: genericService{ID: "API"}}},
+		{&httpsService{genericService: genericService{ID: "servicelist"}}},
+	} {
+		if err := assignIDs(services); err == nil {
+			t.Errorf("expected an error for the id %s", services[0].getConfiguredID())
+		}
+	}
+}
+
+func TestCheckResponseTime(t *testing.T) {
+	limit := genericService{MaxResponseTime: 100 * time.Millisecond}
+	tests := []struct {
+		name     string
+		service  Service
+		raw      rawHistoricDataPoint
+		expected int
+	}{
+		{"slow port", &portService{genericService: limit}, rawHistoricDataPoint{0, portOpen, 150, "", 1, nil}, responseTooSlow},
+		{"fast port", &portService{genericService: limit}, rawHistoricDataPoint{0, portOpen, 50, "", 1, nil}, portOpen},
+		{"closed port", &portService{genericService: limit}, rawHistoricDataPoint{0, portConnectionError, 150, "", 1, nil}, portConnectionError},
+		{"slow dns", &dnsService{genericService: limit}, rawHistoricDataPoint{0, dnsResolved, 150, "", 1, nil}, responseTooSlow},
+		{"slow ping", &pingService{genericService: limit}, rawHistoricDataPoint{0, pingSuccess, 150, "", 1, nil}, responseTooSlow},
+		{"ping with packet loss", &pingService{genericService: limit}, rawHistoricDataPoint{0, pingPacketLoss, 150, "", 1, nil}, pingPacketLoss},
+		{"slow https", &httpsService{genericService: limit}, rawHistoricDataPoint{0, 200, 150, "", 1, nil}, responseTooSlow},
+		{"no limit", &portService{}, rawHistoricDataPoint{0, portOpen, 150, "", 1, nil}, portOpen},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dataPoint := checkResponseTime(test.service, test.service.newDataPoint(test.raw))
+			if dataPoint.GetStatusCode() != test.expected {
+				t.Fatalf("expected status code %d, got %d", test.expected, dataPoint.GetStatusCode())
+			}
+			if test.expected == responseTooSlow && (!dataPoint.IsUp() || !dataPoint.IsDegraded()) {
+				t.Errorf("expected a slow response to be up and degraded: %s", dataPoint.GetStatusMessage())
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+type dnsService struct {
+	genericService `mapstructure:",squash"`
+
+	// RecordType is one of A, AAAA, CNAME, MX and TXT
+	RecordType string `json:"recordType" validate:"oneof=A AAAA CNAME MX TXT,ignorecase"`
+	// Resolver is the address of the DNS server, the system resolver is used if it is empty
+	Resolver string   `json:"resolver"`
+	Expected []string `json:"expected"`
+	// ExactMatch also marks the service as down if a record is not part of Expected
+	ExactMatch bool          `json:"exactMatch"`
+	Timeout    time.Duration `json:"timeout"`
+}
+
+type dnsHistoricDataPoint struct {
+	rawHistoricDataPoint
+	service *dnsService
+}
+
+const (
+	dnsResolved         = 200
+	dnsLookupError      = 600
+	dnsTimeout          = 601
+	dnsUnexpectedAnswer = 608
+	dnsNotFound         = 609
+)
+
+const defaultDNSTimeout = 5 * time.Second
+
+var dnsStatusText = map[int]string{
+	dnsResolved:         "OK",
+	dnsLookupError:      "Lookup error",
+	dnsTimeout:          "Lookup timed out",
+	dnsUnexpectedAnswer: "Unexpected answer",
+	dnsNotFound:         "Record not found",
+}
+
+func (service *dnsService) crawl(ctx context.Context) rawHistoricDataPoint {
+	var statusCode int
+	var statusMessage string
+	var responseTime int64
+
+	if service.IsDisabled() {
+		statusCode = -1
+		statusMessage = "The service is disabled"
+		responseTime = -1
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, service.getTimeout())
+		start := time.Now()
+		records, err := service.lookup(ctx)
+		responseTime = time.Since(start).Milliseconds()
+		cancel()
+
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			statusCode = dnsNotFound
+			statusMessage = err.Error()
+		} else if isTimeout(err) {
+			statusCode = dnsTimeout
+			statusMessage = err.Error()
+		} else if err != nil {
+			statusCode = dnsLookupError
+			statusMessage = err.Error()
+		} else if missing := missingRecords(service.Expected, records); len(missing) > 0 {
+			statusCode = dnsUnexpectedAnswer
+			statusMessage = fmt.Sprintf("Expected %s but received %s", strings.Join(missing, ", "), strings.Join(records, ", "))
+		} else if unexpected := missingRecords(records, service.Expected); service.ExactMatch && len(service.Expected) > 0 && len(unexpected) > 0 {
+			statusCode = dnsUnexpectedAnswer
+			statusMessage = fmt.Sprintf("Received unexpected %s", strings.Join(unexpected, ", "))
+		} else {
+			statusCode = dnsResolved
+			statusMessage = ""
+		}
+	}
+
+	return rawHistoricDataPoint{time.Now().Unix(), statusCode, responseTime, statusMessage, 1, nil}
+}
+
+func (service *dnsService) newDataPoint(rawDataPoint rawHistoricDataPoint) HistoricDataPoint {
+	return dnsHistoricDataPoint{rawDataPoint, service}
+}
+
+// lookup resolves the configured record of the host
+func (service *dnsService) lookup(ctx context.Context) ([]string, error) {
+	resolver := service.getResolver()
+	records := make([]string, 0)
+
+	recordType := strings.ToUpper(service.getRecordType())
+	switch recordType {
+	case "A", "AAAA":
+		network := "ip4"
+		if recordType == "AAAA" {
+			network = "ip6"
+		}
+		ips, err := resolver.LookupIP(ctx, network, service.Host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			records = append(records, ip.String())
+		}
+	case "CNAME":
+		cname, err := resolver.LookupCNAME(ctx, service.Host)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, cname)
+	case "MX":
+		mxs, err := resolver.LookupMX(ctx, service.Host)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			records = append(records, mx.Host)
+		}
+	case "TXT":
+		txts, err := resolver.LookupTXT(ctx, service.Host)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, txts...)
+	default:
+		return nil, errors.New("Unsupported record type " + service.RecordType)
+	}
+
+	return records, nil
+}
+
+func (service *dnsService) getResolver() *net.Resolver {
+	if len(service.Resolver) == 0 {
+		return net.DefaultResolver
+	}
+
+	address := service.Resolver
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "53")
+	}
+
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			return dialer.DialContext(ctx, network, address)
+		},
+	}
+}
+
+func (service *dnsService) setHistoricData(rawData []rawHistoricDataPoint) {
+	service.historicData = make([]HistoricDataPoint, len(rawData))
+	for i, rawDataPoint := range rawData {
+		service.historicData[i] = dnsHistoricDataPoint{rawDataPoint, service}
+	}
+}
+
+func (service *dnsService) GetType() string {
+	return "dns"
+}
+
+func (service *dnsService) getRecordType() string {
+	if len(service.RecordType) == 0 {
+		return "A"
+	}
+	return service.RecordType
+}
+
+func (service *dnsService) getTimeout() time.Duration {
+	if service.Timeout <= 0 {
+		return defaultDNSTimeout
+	}
+	return service.Timeout
+}
+
+// == data point ==
+
+func (dataPoint dnsHistoricDataPoint) IsUp() bool {
+	return dataPoint.StatusCode == dnsResolved || dataPoint.IsDegraded()
+}
+
+func (dataPoint dnsHistoricDataPoint) GetStatusMessage() string {
+	if len(dataPoint.StatusMessage) > 0 {
+		return dataPoint.StatusMessage
+	}
+	return dnsStatusText[dataPoint.StatusCode]
+}
+
+// == helper ==
+
+// missingRecords returns all expected records which are not contained in records.
+// Names are compared case insensitive and without the trailing dot.
+func missingRecords(expected []string, records []string) []string {
+	missing := make([]string, 0)
+	for _, expectedRecord := range expected {
+		found := false
+		for _, record := range records {
+			if strings.EqualFold(strings.TrimSuffix(record, "."), strings.TrimSuffix(expectedRecord, ".")) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, expectedRecord)
+		}
+	}
+	return missing
+}
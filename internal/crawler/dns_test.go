@@ -0,0 +1,127 @@
+package crawler
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// stubDNSServer answers queries from its records over UDP. Unknown names are answered with NXDOMAIN,
+// queries for names in silent are never answered. The records are read without a lock, so they are
+// added before the server is started.
+type stubDNSServer struct {
+	conn    net.PacketConn
+	records map[string][]dnsmessage.Resource
+	silent  map[string]bool
+}
+
+func newStubDNSServer(t *testing.T) *stubDNSServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &stubDNSServer{conn: conn, records: make(map[string][]dnsmessage.Resource), silent: make(map[string]bool)}
+}
+
+func (server *stubDNSServer) start() {
+	go server.serve()
+}
+
+func (server *stubDNSServer) add(name string, recordType dnsmessage.Type, body dnsmessage.ResourceBody) {
+	header := dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: recordType, Class: dnsmessage.ClassINET, TTL: 60}
+	server.records[name] = append(server.records[name], dnsmessage.Resource{Header: header, Body: body})
+}
+
+func (server *stubDNSServer) serve() {
+	buffer := make([]byte, 512)
+	for {
+		n, addr, err := server.conn.ReadFrom(buffer)
+		if err != nil {
+			return
+		}
+
+		var query dnsmessage.Message
+		if err := query.Unpack(buffer[:n]); err != nil || len(query.Questions) != 1 {
+			continue
+		}
+		question := query.Questions[0]
+		if server.silent[question.Name.String()] {
+			continue
+		}
+
+		response := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true, RecursionAvailable: true},
+			Questions: query.Questions,
+		}
+		records, ok := server.records[question.Name.String()]
+		if !ok {
+			response.RCode = dnsmessage.RCodeNameError
+		}
+		for _, record := range records {
+			// a CNAME is part of the answer to queries of every type
+			if record.Header.Type == question.Type || record.Header.Type == dnsmessage.TypeCNAME {
+				response.Answers = append(response.Answers, record)
+			}
+		}
+
+		packed, err := response.Pack()
+		if err == nil {
+			server.conn.WriteTo(packed, addr)
+		}
+	}
+}
+
+func TestDNSService(t *testing.T) {
+	server := newStubDNSServer(t)
+	server.add("a.test.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}})
+	server.add("a.test.", dnsmessage.TypeA, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}})
+	server.add("aaaa.test.", dnsmessage.TypeAAAA, &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}})
+	server.add("www.test.", dnsmessage.TypeCNAME, &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("a.test.")})
+	server.add("mx.test.", dnsmessage.TypeMX, &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.test.")})
+	server.add("txt.test.", dnsmessage.TypeTXT, &dnsmessage.TXTResource{TXT: []string{"v=spf1 -all"}})
+	server.silent["slow.test."] = true
+	server.start()
+
+	tests := []struct {
+		name       string
+		host       string
+		recordType string
+		expected   []string
+		exactMatch bool
+		statusCode int
+	}{
+		{"A", "a.test.", "A", []string{"192.0.2.1"}, false, dnsResolved},
+		{"A with another record", "a.test.", "A", []string{"192.0.2.3"}, false, dnsUnexpectedAnswer},
+		{"A with exact match", "a.test.", "A", []string{"192.0.2.1", "192.0.2.2"}, true, dnsResolved},
+		{"A with an injected record", "a.test.", "A", []string{"192.0.2.1"}, true, dnsUnexpectedAnswer},
+		{"AAAA", "aaaa.test.", "AAAA", []string{"2001:db8::1"}, false, dnsResolved},
+		{"CNAME", "www.test.", "CNAME", []string{"a.test"}, false, dnsResolved},
+		{"MX", "mx.test.", "MX", []string{"MAIL.test."}, false, dnsResolved},
+		{"TXT", "txt.test.", "TXT", []string{"v=spf1 -all"}, false, dnsResolved},
+		{"NXDOMAIN", "missing.test.", "A", nil, false, dnsNotFound},
+		{"timeout", "slow.test.", "A", nil, false, dnsTimeout},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &dnsService{
+				RecordType: test.recordType,
+				Resolver:   server.conn.LocalAddr().String(),
+				Expected:   test.expected,
+				ExactMatch: test.exactMatch,
+				Timeout:    500 * time.Millisecond,
+			}
+			service.Host = test.host
+
+			dataPoint := service.crawl(context.Background())
+			if dataPoint.StatusCode != test.statusCode {
+				t.Errorf("expected status %d, got %d: %s", test.statusCode, dataPoint.StatusCode, dataPoint.StatusMessage)
+			}
+		})
+	}
+}
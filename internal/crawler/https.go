@@ -1,8 +1,18 @@
 package crawler
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -11,6 +21,30 @@ type httpsService struct {
 
 	Path             string `json:"path"`
 	ValidStatusCodes []int  `json:"validStatusCodes"`
+
+	// URL replaces https://<host><path> and may use plain http or a custom port
+	URL string `json:"url"`
+	// CAFile is a PEM bundle which replaces the system root certificates
+	CAFile             string `json:"caFile"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+
+	Method string `json:"method"`
+	// Headers and Body may reference environment variables like ${TOKEN}
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	Timeout         time.Duration     `json:"timeout"`
+	FollowRedirects *bool             `json:"followRedirects"`
+	// client is reused by all crawls, so its idle connections are kept alive instead of leaked
+	client      *http.Client
+	clientMutex sync.Mutex
+
+	// CertificateExpiryThreshold marks the service as degraded if its certificate expires in fewer days
+	CertificateExpiryThreshold int `json:"certificateExpiryThreshold"`
+	certificate                *CertificateInfo
+	certificateMutex           sync.RWMutex
+
+	// Assertions are evaluated against the JSON response body
+	Assertions []jsonAssertion `json:"assertions"`
 }
 
 type httpsHistoricDataPoint struct {
@@ -18,9 +52,19 @@ type httpsHistoricDataPoint struct {
 	service *httpsService
 }
 
-const httpsRequestError = 600
+const (
+	httpsRequestError = 600
+)
 
-func (service *httpsService) crawl() HistoricDataPoint {
+// httpsDegradedStatusCodes are used if the service responded but not as expected
+var httpsDegradedStatusCodes = []int{certificateExpiring, responseTooSlow, jsonAssertionDegraded}
+
+const (
+	defaultHTTPSTimeout = 10 * time.Second
+	maxHTTPSBodySize    = 10 << 20
+)
+
+func (service *httpsService) crawl(ctx context.Context) rawHistoricDataPoint {
 	var statusCode int
 	var statusMessage string
 	var responseTime int64
@@ -30,23 +74,137 @@ func (service *httpsService) crawl() HistoricDataPoint {
 		statusMessage = "The service is disabled"
 		responseTime = -1
 	} else {
-		start := time.Now()
-		resp, err := http.Get(fmt.Sprintf("https://%s%s", service.Host, service.Path))
+		statusCode, statusMessage, responseTime = service.check(ctx)
+	}
+
+	return rawHistoricDataPoint{time.Now().Unix(), statusCode, responseTime, statusMessage, 1, nil}
+}
+
+func (service *httpsService) newDataPoint(rawDataPoint rawHistoricDataPoint) HistoricDataPoint {
+	return httpsHistoricDataPoint{rawDataPoint, service}
+}
+
+func (service *httpsService) check(ctx context.Context) (int, string, int64) {
+	resp, elapsed, err := service.fetch(ctx)
+	if err != nil {
+		statusCode, statusMessage := requestErrorStatus(err)
+		return statusCode, statusMessage, elapsed.Milliseconds()
+	}
+	defer resp.Body.Close()
+
+	if !service.isValidStatusCode(resp.StatusCode) {
+		return resp.StatusCode, "", elapsed.Milliseconds()
+	}
+
+	if len(service.Assertions) > 0 {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPSBodySize))
 		if err != nil {
-			statusCode = httpsRequestError
-			statusMessage = err.Error()
-		} else {
-			statusCode = resp.StatusCode
-			statusMessage = ""
+			return httpsRequestError, err.Error(), elapsed.Milliseconds()
+		}
+		if statusCode, err := checkJSONAssertions(service.Assertions, body); err != nil {
+			return statusCode, err.Error(), elapsed.Milliseconds()
 		}
-		responseTime = time.Since(start).Milliseconds()
 	}
 
-	rawDataPoint := rawHistoricDataPoint{time.Now().Unix(), statusCode, responseTime, statusMessage}
-	dataPoint := httpsHistoricDataPoint{rawDataPoint, service}
-	service.historicData = append(service.historicData, dataPoint)
+	return service.checkDegradation(resp, elapsed)
+}
 
-	return dataPoint
+// checkDegradation returns a degraded status code if the certificate expires soon.
+// Slow responses are checked for all types of services in crawlService.
+func (service *httpsService) checkDegradation(resp *http.Response, elapsed time.Duration) (int, string, int64) {
+	if ok, statusCode, statusMessage := service.checkCertificate(resp); !ok {
+		return statusCode, statusMessage, elapsed.Milliseconds()
+	}
+	return resp.StatusCode, "", elapsed.Milliseconds()
+}
+
+// fetch requests the service and returns the response and the time it took
+func (service *httpsService) fetch(ctx context.Context) (*http.Response, time.Duration, error) {
+	var body io.Reader
+	if len(service.Body) > 0 {
+		body = strings.NewReader(ExpandEnv(service.Body))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, service.getMethod(), service.getURL(), body)
+	if err != nil {
+		return nil, 0, err
+	}
+	for name, value := range service.Headers {
+		req.Header.Set(name, ExpandEnv(value))
+	}
+
+	client, err := service.getClient()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	return resp, time.Since(start), err
+}
+
+func (service *httpsService) getClient() (*http.Client, error) {
+	service.clientMutex.Lock()
+	defer service.clientMutex.Unlock()
+	if service.client != nil {
+		return service.client, nil
+	}
+
+	tlsConfig, err := service.getTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	client := &http.Client{Transport: transport, Timeout: service.getTimeout()}
+	if service.FollowRedirects != nil && !*service.FollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+	}
+	service.client = client
+	return client, nil
+}
+
+func (service *httpsService) getTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: service.InsecureSkipVerify}
+	if len(service.CAFile) == 0 {
+		return tlsConfig, nil
+	}
+
+	caBundle, err := os.ReadFile(service.CAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig.RootCAs = x509.NewCertPool()
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(caBundle) {
+		return nil, errors.New("No certificates found in " + service.CAFile)
+	}
+	return tlsConfig, nil
+}
+
+func (service *httpsService) getURL() string {
+	if len(service.URL) > 0 {
+		return service.URL
+	}
+	return fmt.Sprintf("https://%s%s", service.Host, service.Path)
+}
+
+func (service *httpsService) getMethod() string {
+	if len(service.Method) == 0 {
+		return http.MethodGet
+	}
+	return strings.ToUpper(service.Method)
+}
+
+func (service *httpsService) getTimeout() time.Duration {
+	if service.Timeout <= 0 {
+		return defaultHTTPSTimeout
+	}
+	return service.Timeout
 }
 
 func (service *httpsService) setHistoricData(rawData []rawHistoricDataPoint) {
@@ -56,17 +214,39 @@ func (service *httpsService) setHistoricData(rawData []rawHistoricDataPoint) {
 	}
 }
 
+// GetHost falls back to the host of the URL if no host is configured
+func (service *httpsService) GetHost() string {
+	if len(service.Host) > 0 || len(service.URL) == 0 {
+		return service.Host
+	}
+	if parsedURL, err := url.Parse(service.URL); err == nil {
+		return parsedURL.Host
+	}
+	return service.URL
+}
+
 func (service *httpsService) GetType() string {
+	if strings.HasPrefix(strings.ToLower(service.URL), "http://") {
+		return "http"
+	}
 	return "https"
 }
 
+func (service *httpsService) isValidStatusCode(statusCode int) bool {
+	if service.ValidStatusCodes == nil {
+		return statusCode >= http.StatusOK && statusCode <= http.StatusPermanentRedirect
+	}
+	return sliceContains(service.ValidStatusCodes, statusCode)
+}
+
 // == data point ==
 
 func (dataPoint httpsHistoricDataPoint) IsUp() bool {
-	if dataPoint.service.ValidStatusCodes == nil {
-		return dataPoint.StatusCode >= http.StatusOK && dataPoint.StatusCode <= http.StatusPermanentRedirect
-	}
-	return sliceContains(dataPoint.service.ValidStatusCodes, dataPoint.StatusCode)
+	return dataPoint.service.isValidStatusCode(dataPoint.StatusCode) || dataPoint.IsDegraded()
+}
+
+func (dataPoint httpsHistoricDataPoint) IsDegraded() bool {
+	return sliceContains(httpsDegradedStatusCodes, dataPoint.StatusCode)
 }
 
 func (dataPoint httpsHistoricDataPoint) GetStatusMessage() string {
@@ -79,6 +259,16 @@ func (dataPoint httpsHistoricDataPoint) GetStatusMessage() string {
 
 // == helper ==
 
+var envReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ExpandEnv replaces references like ${TOKEN} with the environment variable.
+// Unlike os.ExpandEnv it leaves a bare $name alone, GraphQL queries and passwords in notification URLs contain those.
+func ExpandEnv(value string) string {
+	return envReference.ReplaceAllStringFunc(value, func(reference string) string {
+		return os.Getenv(envReference.FindStringSubmatch(reference)[1])
+	})
+}
+
 func sliceContains(haystack []int, needle int) bool {
 	for _, element := range haystack {
 		if element == needle {
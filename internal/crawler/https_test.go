@@ -0,0 +1,73 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("DOWNTIMEROBOT_TEST_TOKEN", "secret")
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"Bearer ${DOWNTIMEROBOT_TEST_TOKEN}", "Bearer secret"},
+		{"${DOWNTIMEROBOT_TEST_UNSET}", ""},
+		{`{"query": "query($id: ID!) { node(id: $id) { id } }"}`, `{"query": "query($id: ID!) { node(id: $id) { id } }"}`},
+		{"$DOWNTIMEROBOT_TEST_TOKEN and $$ and ${not a name}", "$DOWNTIMEROBOT_TEST_TOKEN and $$ and ${not a name}"},
+	}
+
+	for _, test := range tests {
+		if actual := ExpandEnv(test.value); actual != test.expected {
+			t.Errorf("expected %q for %q, got %q", test.expected, test.value, actual)
+		}
+	}
+}
+
+func TestHTTPSServiceSendsExpandedRequest(t *testing.T) {
+	t.Setenv("DOWNTIMEROBOT_TEST_TOKEN", "secret")
+
+	var mutex sync.Mutex
+	var authorization, body string
+	remoteAddrs := make(map[string]bool)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestBody, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		authorization = r.Header.Get("Authorization")
+		body = string(requestBody)
+		remoteAddrs[r.RemoteAddr] = true
+	}))
+	defer server.Close()
+
+	service := &httpsService{
+		URL:     server.URL,
+		Method:  "post",
+		Headers: map[string]string{"Authorization": "Bearer ${DOWNTIMEROBOT_TEST_TOKEN}"},
+		Body:    `{"query": "query($id: ID!) { node(id: $id) { id } }"}`,
+	}
+
+	for i := 0; i < 3; i++ {
+		if dataPoint := service.crawl(context.Background()); dataPoint.StatusCode != http.StatusOK {
+			t.Fatalf("expected status code 200, got %d: %s", dataPoint.StatusCode, dataPoint.StatusMessage)
+		}
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if authorization != "Bearer secret" {
+		t.Errorf("expected the token in the header, got %q", authorization)
+	}
+	if body != service.Body {
+		t.Errorf("expected the body unchanged, got %q", body)
+	}
+	// the connection of the first crawl is reused instead of leaking a new transport every crawl
+	if len(remoteAddrs) != 1 {
+		t.Errorf("expected all crawls to share one connection, got %d", len(remoteAddrs))
+	}
+}
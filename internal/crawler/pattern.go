@@ -1,17 +1,134 @@
 package crawler
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
 
 type patternService struct {
-	Status      string    `json:"status"`
-	Name        string    `json:"name"`
-	DailyRatios []float32 `json:"dailyRatios"`
+	httpsService `mapstructure:",squash"`
 
-	Path    string `json:"path"`
-	Pattern string `json:"pattern"`
+	// Pattern is a regular expression unless Literal is set
+	Pattern string `json:"pattern" validate:"regexp,unless=literal"`
+	Literal bool   `json:"literal"`
+	// Negate marks the service as down if the pattern is found
+	Negate bool `json:"negate"`
 }
 
-func (service *patternService) Crawl() error {
-	fmt.Println("Pattern")
-	return nil
+type patternHistoricDataPoint struct {
+	rawHistoricDataPoint
+	service *patternService
+}
+
+const (
+	patternMismatch = 604
+	patternInvalid  = 605
+)
+
+var patternStatusText = map[int]string{
+	httpsRequestError: "Request error",
+	patternMismatch:   "Pattern mismatch",
+	patternInvalid:    "Invalid pattern",
+}
+
+func (service *patternService) crawl(ctx context.Context) rawHistoricDataPoint {
+	var statusCode int
+	var statusMessage string
+	var responseTime int64
+
+	if service.IsDisabled() {
+		statusCode = -1
+		statusMessage = "The service is disabled"
+		responseTime = -1
+	} else {
+		statusCode, statusMessage, responseTime = service.check(ctx)
+	}
+
+	return rawHistoricDataPoint{time.Now().Unix(), statusCode, responseTime, statusMessage, 1, nil}
+}
+
+func (service *patternService) newDataPoint(rawDataPoint rawHistoricDataPoint) HistoricDataPoint {
+	return patternHistoricDataPoint{rawDataPoint, service}
+}
+
+func (service *patternService) check(ctx context.Context) (int, string, int64) {
+	matcher, err := service.compilePattern()
+	if err != nil {
+		return patternInvalid, err.Error(), -1
+	}
+
+	resp, elapsed, err := service.fetch(ctx)
+	if err != nil {
+		statusCode, statusMessage := requestErrorStatus(err)
+		return statusCode, statusMessage, elapsed.Milliseconds()
+	}
+	defer resp.Body.Close()
+
+	if !service.isValidStatusCode(resp.StatusCode) {
+		return resp.StatusCode, "", elapsed.Milliseconds()
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPSBodySize))
+	if err != nil {
+		return httpsRequestError, err.Error(), elapsed.Milliseconds()
+	}
+
+	found := matcher(string(body))
+	if found && service.Negate {
+		return patternMismatch, fmt.Sprintf("Pattern %q was found", service.Pattern), elapsed.Milliseconds()
+	} else if !found && !service.Negate {
+		return patternMismatch, fmt.Sprintf("Pattern %q was not found", service.Pattern), elapsed.Milliseconds()
+	}
+
+	return service.checkDegradation(resp, elapsed)
+}
+
+func (service *patternService) compilePattern() (func(string) bool, error) {
+	if service.Literal {
+		return func(body string) bool {
+			return strings.Contains(body, service.Pattern)
+		}, nil
+	}
+
+	pattern, err := regexp.Compile(service.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	return pattern.MatchString, nil
+}
+
+func (service *patternService) setHistoricData(rawData []rawHistoricDataPoint) {
+	service.historicData = make([]HistoricDataPoint, len(rawData))
+	for i, rawDataPoint := range rawData {
+		service.historicData[i] = patternHistoricDataPoint{rawDataPoint, service}
+	}
+}
+
+func (service *patternService) GetType() string {
+	return "pattern"
+}
+
+// == data point ==
+
+func (dataPoint patternHistoricDataPoint) IsUp() bool {
+	return dataPoint.service.isValidStatusCode(dataPoint.StatusCode) || dataPoint.IsDegraded()
+}
+
+func (dataPoint patternHistoricDataPoint) IsDegraded() bool {
+	return sliceContains(httpsDegradedStatusCodes, dataPoint.StatusCode)
+}
+
+func (dataPoint patternHistoricDataPoint) GetStatusMessage() string {
+	if len(dataPoint.StatusMessage) > 0 {
+		return dataPoint.StatusMessage
+	}
+	if message, ok := patternStatusText[dataPoint.StatusCode]; ok {
+		return message
+	}
+	return http.StatusText(dataPoint.StatusCode)
 }
@@ -0,0 +1,53 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPatternService(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("<html><body>All systems operational (v1.2)</body></html>"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		path       string
+		pattern    string
+		literal    bool
+		negate     bool
+		statusCode int
+	}{
+		{"regular expression matches", "/", `operational \(v\d+\.\d+\)`, false, false, http.StatusOK},
+		{"regular expression does not match", "/", `outage`, false, false, patternMismatch},
+		{"literal matches", "/", "(v1.2)", true, false, http.StatusOK},
+		{"literal is no regular expression", "/", "v1.2)", true, false, http.StatusOK},
+		{"literal does not match", "/", "v1x2", true, false, patternMismatch},
+		{"negated pattern is not found", "/", "outage", false, true, http.StatusOK},
+		{"negated pattern is found", "/", "operational", false, true, patternMismatch},
+		{"invalid regular expression", "/", "(v1", false, false, patternInvalid},
+		{"invalid status code", "/missing", "operational", false, false, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &patternService{Pattern: test.pattern, Literal: test.literal, Negate: test.negate}
+			service.URL = server.URL + test.path
+
+			dataPoint := service.newDataPoint(service.crawl(context.Background()))
+			if dataPoint.GetStatusCode() != test.statusCode {
+				t.Fatalf("expected status code %d, got %d: %s", test.statusCode, dataPoint.GetStatusCode(), dataPoint.GetStatusMessage())
+			}
+			if dataPoint.IsUp() != (test.statusCode == http.StatusOK) {
+				t.Errorf("expected up to be %v for status code %d", test.statusCode == http.StatusOK, test.statusCode)
+			}
+		})
+	}
+}
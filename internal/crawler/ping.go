@@ -1,16 +1,281 @@
 package crawler
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
 
-type PingService struct {
-	Status      string    `json:"status"`
-	Name        string    `json:"name"`
-	Url         string    `json:"url"`
-	DailyRatios []float32 `json:"dailyRatios"`
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+type pingService struct {
+	genericService `mapstructure:",squash"`
+
+	Count   int           `json:"count"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+type pingHistoricDataPoint struct {
+	rawHistoricDataPoint
+	service *pingService
+}
+
+type pingResult struct {
+	sent             int
+	received         int
+	averageRoundTrip time.Duration
+}
+
+type icmpNetwork struct {
+	privileged   string
+	unprivileged string
+	listenAddr   string
+	protocol     int
+	echoRequest  icmp.Type
+	echoReply    icmp.Type
+}
+
+const (
+	pingSuccess      = 200
+	pingRequestError = 600
+	pingTimeout      = 601
+	pingPacketLoss   = 602
+)
+
+const (
+	defaultPingCount   = 3
+	defaultPingTimeout = 2 * time.Second
+)
+
+var pingStatusText = map[int]string{
+	pingSuccess:      "OK",
+	pingRequestError: "Request error",
+	pingTimeout:      "Request timed out",
+	pingPacketLoss:   "Packet loss",
+}
+
+// lastEchoID makes the echo ID of every ping unique, so concurrent pings do not accept each others replies
+var lastEchoID = uint32(os.Getpid())
+
+var icmpv4Network = icmpNetwork{"ip4:icmp", "udp4", "0.0.0.0", 1, ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply}
+var icmpv6Network = icmpNetwork{"ip6:ipv6-icmp", "udp6", "::", 58, ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply}
+
+func (service *pingService) crawl(ctx context.Context) rawHistoricDataPoint {
+	var statusCode int
+	var statusMessage string
+	var responseTime int64
+
+	if service.IsDisabled() {
+		statusCode = -1
+		statusMessage = "The service is disabled"
+		responseTime = -1
+	} else {
+		result, err := ping(ctx, service.Host, service.getCount(), service.getTimeout())
+		if err != nil {
+			statusCode = pingRequestError
+			statusMessage = err.Error()
+		} else if result.received == 0 {
+			statusCode = pingTimeout
+			statusMessage = fmt.Sprintf("No reply to %d packets", result.sent)
+		} else if result.received < result.sent {
+			statusCode = pingPacketLoss
+			statusMessage = fmt.Sprintf("%d of %d packets lost", result.sent-result.received, result.sent)
+		} else {
+			statusCode = pingSuccess
+			statusMessage = ""
+		}
+
+		responseTime = -1
+		if result.received > 0 {
+			responseTime = result.averageRoundTrip.Milliseconds()
+		}
+	}
+
+	return rawHistoricDataPoint{time.Now().Unix(), statusCode, responseTime, statusMessage, 1, nil}
+}
+
+func (service *pingService) newDataPoint(rawDataPoint rawHistoricDataPoint) HistoricDataPoint {
+	return pingHistoricDataPoint{rawDataPoint, service}
+}
+
+func (service *pingService) setHistoricData(rawData []rawHistoricDataPoint) {
+	service.historicData = make([]HistoricDataPoint, len(rawData))
+	for i, rawDataPoint := range rawData {
+		service.historicData[i] = pingHistoricDataPoint{rawDataPoint, service}
+	}
+}
+
+func (service *pingService) GetType() string {
+	return "ping"
+}
+
+func (service *pingService) getCount() int {
+	if service.Count <= 0 {
+		return defaultPingCount
+	}
+	return service.Count
+}
+
+func (service *pingService) getTimeout() time.Duration {
+	if service.Timeout <= 0 {
+		return defaultPingTimeout
+	}
+	return service.Timeout
+}
+
+// == data point ==
+
+// IsUp returns true if at least one echo request was answered
+func (dataPoint pingHistoricDataPoint) IsUp() bool {
+	return dataPoint.StatusCode == pingSuccess || dataPoint.IsDegraded()
+}
+
+func (dataPoint pingHistoricDataPoint) IsDegraded() bool {
+	return dataPoint.StatusCode == pingPacketLoss || dataPoint.StatusCode == responseTooSlow
+}
+
+func (dataPoint pingHistoricDataPoint) GetStatusMessage() string {
+	if len(dataPoint.StatusMessage) > 0 {
+		return dataPoint.StatusMessage
+	}
+	return pingStatusText[dataPoint.StatusCode]
+}
+
+// == icmp ==
+
+// ping sends count ICMP echo requests to host and waits up to timeout for each reply
+func ping(ctx context.Context, host string, count int, timeout time.Duration) (pingResult, error) {
+	result := pingResult{}
+
+	ipAddrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return result, err
+	}
+	ipAddr := &ipAddrs[0]
+
+	network := icmpv4Network
+	if ipAddr.IP.To4() == nil {
+		network = icmpv6Network
+	}
+
+	conn, privileged, err := listenICMP(network)
+	if err != nil {
+		return result, err
+	}
+	defer conn.Close()
+
+	var destination net.Addr = ipAddr
+	if !privileged {
+		destination = &net.UDPAddr{IP: ipAddr.IP, Zone: ipAddr.Zone}
+	}
+
+	id := nextEchoID()
+	var totalRoundTrip time.Duration
+	for seq := 0; seq < count && ctx.Err() == nil; seq++ {
+		result.sent++
+		roundTrip, err := sendEcho(conn, network, destination, privileged, id, seq, deadline(ctx, timeout))
+		if isTimeout(err) {
+			continue
+		} else if err != nil {
+			return result, err
+		}
+		result.received++
+		totalRoundTrip += roundTrip
+	}
+
+	if result.received > 0 {
+		result.averageRoundTrip = totalRoundTrip / time.Duration(result.received)
+	}
+	return result, nil
 }
 
-func (service *PingService) Crawl() error {
-	fmt.Println("Ping")
+// listenICMP opens a raw ICMP socket and falls back to an unprivileged
+// UDP ping socket if raw sockets are not permitted
+func listenICMP(network icmpNetwork) (*icmp.PacketConn, bool, error) {
+	conn, err := icmp.ListenPacket(network.privileged, network.listenAddr)
+	if err == nil {
+		return conn, true, nil
+	}
 
+	log.WithFields(log.Fields{
+		"network": network.privileged,
+		"err":     err.Error(),
+	}).Debug("Cannot open raw ICMP socket, falling back to unprivileged ping")
+
+	conn, err = icmp.ListenPacket(network.unprivileged, network.listenAddr)
+	return conn, false, err
+}
+
+func sendEcho(conn *icmp.PacketConn, network icmpNetwork, destination net.Addr, privileged bool, id int, seq int, deadline time.Time) (time.Duration, error) {
+	message := icmp.Message{
+		Type: network.echoRequest,
+		Body: &icmp.Echo{ID: id, Seq: seq, Data: []byte("downtimerobot")},
+	}
+	request, err := message.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return 0, err
+	}
+	if _, err := conn.WriteTo(request, destination); err != nil {
+		return 0, err
+	}
+
+	buffer := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return 0, err
+		}
+
+		reply, err := icmp.ParseMessage(network.protocol, buffer[:n])
+		if err != nil {
+			continue
+		}
+		if isEchoReply(reply, network, peer, destination, privileged, id, seq) {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// isEchoReply returns true if the message answers the echo request with id and seq sent to destination.
+// Raw sockets receive the replies to all echo requests of the host, so the peer has to be checked as well.
+func isEchoReply(reply *icmp.Message, network icmpNetwork, peer net.Addr, destination net.Addr, privileged bool, id int, seq int) bool {
+	if reply.Type != network.echoReply || !getAddrIP(peer).Equal(getAddrIP(destination)) {
+		return false
+	}
+
+	// the kernel rewrites the id of unprivileged echo requests
+	echo, ok := reply.Body.(*icmp.Echo)
+	return ok && echo.Seq == seq && (!privileged || echo.ID == id)
+}
+
+// nextEchoID returns a new ID for the echo requests of a ping
+func nextEchoID() int {
+	return int(atomic.AddUint32(&lastEchoID, 1) & 0xffff)
+}
+
+func getAddrIP(addr net.Addr) net.IP {
+	switch addr := addr.(type) {
+	case *net.IPAddr:
+		return addr.IP
+	case *net.UDPAddr:
+		return addr.IP
+	}
 	return nil
 }
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,97 @@
+package crawler
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/icmp"
+)
+
+func TestIsEchoReply(t *testing.T) {
+	destination := &net.IPAddr{IP: net.ParseIP("192.0.2.1")}
+	reply := &icmp.Message{
+		Type: icmpv4Network.echoReply,
+		Body: &icmp.Echo{ID: 42, Seq: 1},
+	}
+
+	tests := []struct {
+		name       string
+		peer       net.Addr
+		privileged bool
+		id         int
+		seq        int
+		expected   bool
+	}{
+		{"matching reply", &net.IPAddr{IP: net.ParseIP("192.0.2.1")}, true, 42, 1, true},
+		{"reply from another host", &net.IPAddr{IP: net.ParseIP("127.0.0.1")}, true, 42, 1, false},
+		{"reply to another ping", &net.IPAddr{IP: net.ParseIP("192.0.2.1")}, true, 43, 1, false},
+		{"reply to another sequence", &net.IPAddr{IP: net.ParseIP("192.0.2.1")}, true, 42, 2, false},
+		{"unprivileged reply with rewritten id", &net.UDPAddr{IP: net.ParseIP("192.0.2.1")}, false, 43, 1, true},
+		{"unprivileged reply from another host", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")}, false, 42, 1, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var dst net.Addr = destination
+			if !test.privileged {
+				dst = &net.UDPAddr{IP: destination.IP}
+			}
+			if actual := isEchoReply(reply, icmpv4Network, test.peer, dst, test.privileged, test.id, test.seq); actual != test.expected {
+				t.Errorf("expected %v, got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestNextEchoIDIsUnique(t *testing.T) {
+	if nextEchoID() == nextEchoID() {
+		t.Error("expected different echo ids")
+	}
+}
+
+func TestConcurrentPingsDoNotShareReplies(t *testing.T) {
+	conn, _, err := listenICMP(icmpv4Network)
+	if err != nil {
+		t.Skipf("Cannot open ICMP socket: %s", err.Error())
+	}
+	conn.Close()
+
+	unreachableHost := findUnreachableHost(t)
+
+	// the replies from localhost arrive while the unreachable host is pinged
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for ctx.Err() == nil {
+			ping(ctx, "127.0.0.1", 3, 100*time.Millisecond)
+		}
+	}()
+
+	unreachable, unreachableErr := ping(context.Background(), unreachableHost, 3, 500*time.Millisecond)
+	cancel()
+	wg.Wait()
+
+	if unreachableErr != nil {
+		t.Fatal(unreachableErr)
+	}
+	if unreachable.received != 0 {
+		t.Errorf("expected no replies from %s, got %d of %d", unreachableHost, unreachable.received, unreachable.sent)
+	}
+}
+
+// findUnreachableHost returns an address which does not answer pings, some networks answer the documentation ranges
+func findUnreachableHost(t *testing.T) string {
+	for _, host := range []string{"192.0.2.1", "198.51.100.1", "203.0.113.1", "10.255.255.1"} {
+		result, err := ping(context.Background(), host, 1, 500*time.Millisecond)
+		if err == nil && result.received == 0 {
+			return host
+		}
+	}
+	t.Skip("All candidates for an unreachable host answer pings")
+	return ""
+}
@@ -1,17 +1,180 @@
 package crawler
 
-import "fmt"
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
 
-type PortService struct {
-	Status      string    `json:"status"`
-	Name        string    `json:"name"`
-	Url         string    `json:"url"`
-	DailyRatios []float32 `json:"dailyRatios"`
+type portService struct {
+	genericService `mapstructure:",squash"`
 
-	Port int `json:"port"`
+	Port    int           `json:"port"`
+	Timeout time.Duration `json:"timeout"`
+
+	// Send is written to the connection after it was established
+	Send string `json:"send"`
+	// Expect has to be contained in the first bytes the service responds with
+	Expect string `json:"expect"`
+}
+
+type portHistoricDataPoint struct {
+	rawHistoricDataPoint
+	service *portService
+}
+
+const (
+	portOpen               = 200
+	portConnectionError    = 600
+	portTimeout            = 601
+	portUnexpectedResponse = 603
+)
+
+const (
+	defaultPortTimeout = 5 * time.Second
+	maxPortBannerSize  = 4096
+)
+
+var portStatusText = map[int]string{
+	portOpen:               "OK",
+	portConnectionError:    "Connection error",
+	portTimeout:            "Connection timed out",
+	portUnexpectedResponse: "Unexpected response",
+}
+
+func (service *portService) crawl(ctx context.Context) rawHistoricDataPoint {
+	var statusCode int
+	var statusMessage string
+	var responseTime int64
+
+	if service.IsDisabled() {
+		statusCode = -1
+		statusMessage = "The service is disabled"
+		responseTime = -1
+	} else {
+		connectTime, err := service.check(ctx)
+		if isTimeout(err) {
+			statusCode = portTimeout
+			statusMessage = err.Error()
+		} else if _, ok := err.(unexpectedResponseError); ok {
+			statusCode = portUnexpectedResponse
+			statusMessage = err.Error()
+		} else if err != nil {
+			statusCode = portConnectionError
+			statusMessage = err.Error()
+		} else {
+			statusCode = portOpen
+			statusMessage = ""
+		}
+
+		responseTime = -1
+		if connectTime > 0 {
+			responseTime = connectTime.Milliseconds()
+		}
+	}
+
+	return rawHistoricDataPoint{time.Now().Unix(), statusCode, responseTime, statusMessage, 1, nil}
+}
+
+func (service *portService) newDataPoint(rawDataPoint rawHistoricDataPoint) HistoricDataPoint {
+	return portHistoricDataPoint{rawDataPoint, service}
+}
+
+// check connects to the port and returns the time it took to establish the connection
+func (service *portService) check(ctx context.Context) (time.Duration, error) {
+	timeout := service.getTimeout()
+	address := net.JoinHostPort(service.Host, strconv.Itoa(service.Port))
+
+	dialer := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	connectTime := time.Since(start)
+
+	if len(service.Send) == 0 && len(service.Expect) == 0 {
+		return connectTime, nil
+	}
+
+	if err := conn.SetDeadline(deadline(ctx, timeout)); err != nil {
+		return connectTime, err
+	}
+
+	if len(service.Send) > 0 {
+		if _, err := conn.Write([]byte(service.Send)); err != nil {
+			return connectTime, err
+		}
+	}
+
+	if len(service.Expect) > 0 {
+		return connectTime, readExpected(conn, []byte(service.Expect))
+	}
+	return connectTime, nil
+}
+
+func (service *portService) setHistoricData(rawData []rawHistoricDataPoint) {
+	service.historicData = make([]HistoricDataPoint, len(rawData))
+	for i, rawDataPoint := range rawData {
+		service.historicData[i] = portHistoricDataPoint{rawDataPoint, service}
+	}
+}
+
+func (service *portService) GetType() string {
+	return "port"
+}
+
+func (service *portService) getTimeout() time.Duration {
+	if service.Timeout <= 0 {
+		return defaultPortTimeout
+	}
+	return service.Timeout
+}
+
+// == data point ==
+
+func (dataPoint portHistoricDataPoint) IsUp() bool {
+	return dataPoint.StatusCode == portOpen || dataPoint.IsDegraded()
+}
+
+func (dataPoint portHistoricDataPoint) GetStatusMessage() string {
+	if len(dataPoint.StatusMessage) > 0 {
+		return dataPoint.StatusMessage
+	}
+	return portStatusText[dataPoint.StatusCode]
+}
+
+// == helper ==
+
+type unexpectedResponseError struct {
+	expected string
+	received []byte
+}
+
+func (err unexpectedResponseError) Error() string {
+	return fmt.Sprintf("Expected %q but received %q", err.expected, err.received)
 }
 
-func (service *PortService) Crawl() error {
-	fmt.Println("Port")
-	return nil
+// readExpected reads from conn until expected was received, the connection is closed
+// or maxPortBannerSize bytes were read
+func readExpected(conn net.Conn, expected []byte) error {
+	received := make([]byte, 0, maxPortBannerSize)
+	buffer := make([]byte, maxPortBannerSize)
+	for len(received) < maxPortBannerSize {
+		n, err := conn.Read(buffer[:maxPortBannerSize-len(received)])
+		received = append(received, buffer[:n]...)
+		if bytes.Contains(received, expected) {
+			return nil
+		}
+		if isTimeout(err) && len(received) == 0 {
+			return err
+		} else if err != nil {
+			break
+		}
+	}
+	return unexpectedResponseError{string(expected), received}
 }
@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+// newPortTestListener answers every connection with the response to the first line it receives,
+// or with the banner right away if respond is nil
+func newPortTestListener(t *testing.T, banner string, respond func(string) string) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if respond == nil {
+					conn.Write([]byte(banner))
+					return
+				}
+				buffer := make([]byte, 512)
+				n, _ := conn.Read(buffer)
+				conn.Write([]byte(respond(string(buffer[:n]))))
+			}()
+		}
+	}()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a port on which nothing listens
+func closedPort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+	return port
+}
+
+func TestPortService(t *testing.T) {
+	banner := newPortTestListener(t, "SSH-2.0-OpenSSH_8.9\r\n", nil)
+	echo := newPortTestListener(t, "", func(request string) string { return "+" + request })
+	silent := newPortTestListener(t, "", func(string) string { return "" })
+
+	tests := []struct {
+		name       string
+		port       int
+		send       string
+		expect     string
+		statusCode int
+	}{
+		{"open port", banner, "", "", portOpen},
+		{"closed port", closedPort(t), "", "", portConnectionError},
+		{"expected banner", banner, "", "SSH-2.0", portOpen},
+		{"unexpected banner", banner, "", "220 ", portUnexpectedResponse},
+		{"expected answer", echo, "PING\r\n", "+PING", portOpen},
+		{"no answer", silent, "PING\r\n", "+PONG", portUnexpectedResponse},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &portService{Port: test.port, Send: test.send, Expect: test.expect, Timeout: time.Second}
+			service.Host = "127.0.0.1"
+
+			dataPoint := service.crawl(context.Background())
+			if dataPoint.StatusCode != test.statusCode {
+				t.Errorf("expected status code %d, got %d: %s", test.statusCode, dataPoint.StatusCode, dataPoint.StatusMessage)
+			}
+			if test.statusCode == portOpen && dataPoint.ResponseTime < 0 {
+				t.Errorf("expected the connect time of an open port, got %d", dataPoint.ResponseTime)
+			}
+		})
+	}
+}
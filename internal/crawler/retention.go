@@ -0,0 +1,238 @@
+package crawler
+
+import (
+	"sort"
+	"time"
+)
+
+// RetentionOptions control how long data points are kept. Raw data points older than Raw
+// are aggregated into hourly rollups, hourly rollups older than Hourly into daily rollups
+// and daily rollups older than Daily are deleted. Without Raw, all raw data points are kept.
+type RetentionOptions struct {
+	Raw    time.Duration `json:"raw"`
+	Hourly time.Duration `json:"hourly"`
+	Daily  time.Duration `json:"daily"`
+}
+
+// Rollup aggregates all data points of a service within one hour or day
+type Rollup struct {
+	// Period is the length of the rollup in seconds, it starts at the timestamp of the data point
+	Period      int64 `json:"p"`
+	Up          int   `json:"u"`
+	Degraded    int   `json:"d"`
+	Down        int   `json:"n"`
+	Maintenance int   `json:"m"`
+	Disabled    int   `json:"x"`
+	// Samples is the number of data points with a response time
+	Samples         int   `json:"s"`
+	MinResponseTime int64 `json:"min"`
+	AvgResponseTime int64 `json:"avg"`
+	MaxResponseTime int64 `json:"max"`
+	// P95ResponseTime is exact for hourly rollups, daily ones use the highest value of their hours
+	P95ResponseTime int64 `json:"p95"`
+}
+
+const (
+	// minRawRetention keeps the raw data points needed for the response times of the statistics
+	minRawRetention        = 24 * time.Hour
+	defaultHourlyRetention = 30 * 24 * time.Hour
+	defaultDailyRetention  = 400 * 24 * time.Hour
+)
+
+// applyRetention downsamples the historic data of all services
+func applyRetention(services []Service, options RetentionOptions) {
+	if options.Raw <= 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, service := range services {
+		rawDataPoints := dataPointsToRawDataPoints(service.GetHistoricData())
+		service.setHistoricData(options.downsample(rawDataPoints, service, now))
+	}
+}
+
+// downsample aggregates the data points according to the options, they have to be sorted by timestamp
+func (options RetentionOptions) downsample(dataPoints []rawHistoricDataPoint, service Service, now time.Time) []rawHistoricDataPoint {
+	rawCutoff := now.Add(-options.getRaw()).Unix()
+	hourlyCutoff := now.Add(-options.getHourly()).Unix()
+	dailyCutoff := now.Add(-options.getDaily()).Unix()
+
+	result := make([]rawHistoricDataPoint, 0, len(dataPoints))
+	var group []rawHistoricDataPoint
+	var groupStart, groupPeriod int64
+
+	flush := func() {
+		if len(group) > 0 {
+			result = append(result, aggregate(group, service, groupStart, groupPeriod))
+			group = nil
+		}
+	}
+
+	for _, dataPoint := range dataPoints {
+		start, period := dataPoint.Timestamp, int64(0)
+		if dataPoint.IsRollup() {
+			period = dataPoint.Rollup.Period
+		}
+
+		if dataPoint.Timestamp < dailyCutoff {
+			continue
+		} else if dataPoint.Timestamp < hourlyCutoff {
+			start, period = startOfDay(dataPoint.Timestamp), 24*60*60
+		} else if dataPoint.Timestamp < rawCutoff && period <= 60*60 {
+			start, period = startOfHour(dataPoint.Timestamp), 60*60
+		} else {
+			flush()
+			result = append(result, dataPoint)
+			continue
+		}
+
+		if start != groupStart || period != groupPeriod {
+			flush()
+			groupStart, groupPeriod = start, period
+		}
+		group = append(group, dataPoint)
+	}
+	flush()
+
+	return result
+}
+
+// aggregate combines raw data points and rollups into one rollup
+func aggregate(dataPoints []rawHistoricDataPoint, service Service, start int64, period int64) rawHistoricDataPoint {
+	if len(dataPoints) == 1 && dataPoints[0].IsRollup() && dataPoints[0].Rollup.Period == period {
+		return dataPoints[0]
+	}
+
+	rollup := &Rollup{Period: period, MinResponseTime: -1}
+	responseTimes := make([]int64, 0)
+	var responseTimeSum int64
+	var p95 int64
+
+	for _, dataPoint := range dataPoints {
+		dataPointRollup := dataPoint.getRollup(service)
+		rollup.Up += dataPointRollup.Up
+		rollup.Degraded += dataPointRollup.Degraded
+		rollup.Down += dataPointRollup.Down
+		rollup.Maintenance += dataPointRollup.Maintenance
+		rollup.Disabled += dataPointRollup.Disabled
+
+		if dataPointRollup.Samples == 0 {
+			continue
+		}
+		rollup.Samples += dataPointRollup.Samples
+		responseTimeSum += dataPointRollup.AvgResponseTime * int64(dataPointRollup.Samples)
+		if rollup.MinResponseTime < 0 || dataPointRollup.MinResponseTime < rollup.MinResponseTime {
+			rollup.MinResponseTime = dataPointRollup.MinResponseTime
+		}
+		if dataPointRollup.MaxResponseTime > rollup.MaxResponseTime {
+			rollup.MaxResponseTime = dataPointRollup.MaxResponseTime
+		}
+		if dataPoint.IsRollup() {
+			if dataPointRollup.P95ResponseTime > p95 {
+				p95 = dataPointRollup.P95ResponseTime
+			}
+		} else {
+			responseTimes = append(responseTimes, dataPoint.ResponseTime)
+		}
+	}
+
+	if rollup.Samples > 0 {
+		rollup.AvgResponseTime = responseTimeSum / int64(rollup.Samples)
+		if exact := percentile(responseTimes, 0.95); exact > p95 {
+			p95 = exact
+		}
+		rollup.P95ResponseTime = p95
+	} else {
+		rollup.MinResponseTime = 0
+	}
+
+	last := dataPoints[len(dataPoints)-1]
+	return rawHistoricDataPoint{start, last.StatusCode, rollup.AvgResponseTime, "", 0, rollup}
+}
+
+// getRollup returns the rollup of the data point or a rollup containing only the data point
+func (dataPoint rawHistoricDataPoint) getRollup(service Service) Rollup {
+	if dataPoint.IsRollup() {
+		return *dataPoint.Rollup
+	}
+
+	rollup := Rollup{}
+	typedDataPoint := service.newDataPoint(dataPoint)
+	if typedDataPoint.IsDisabled() {
+		rollup.Disabled = 1
+	} else if typedDataPoint.IsMaintenance() {
+		rollup.Maintenance = 1
+	} else if typedDataPoint.IsUp() {
+		rollup.Up = 1
+		if typedDataPoint.IsDegraded() {
+			rollup.Degraded = 1
+		}
+	} else {
+		rollup.Down = 1
+	}
+
+	if dataPoint.ResponseTime > 0 {
+		rollup.Samples = 1
+		rollup.MinResponseTime = dataPoint.ResponseTime
+		rollup.AvgResponseTime = dataPoint.ResponseTime
+		rollup.MaxResponseTime = dataPoint.ResponseTime
+		rollup.P95ResponseTime = dataPoint.ResponseTime
+	}
+	return rollup
+}
+
+// == RetentionOptions ==
+
+func (options RetentionOptions) getRaw() time.Duration {
+	if options.Raw < minRawRetention {
+		return minRawRetention
+	}
+	return options.Raw
+}
+
+func (options RetentionOptions) getHourly() time.Duration {
+	hourly := options.Hourly
+	if hourly <= 0 {
+		hourly = defaultHourlyRetention
+	}
+	if hourly < options.getRaw() {
+		return options.getRaw()
+	}
+	return hourly
+}
+
+func (options RetentionOptions) getDaily() time.Duration {
+	daily := options.Daily
+	if daily <= 0 {
+		daily = defaultDailyRetention
+	}
+	if daily < options.getHourly() {
+		return options.getHourly()
+	}
+	return daily
+}
+
+// == helper ==
+
+func startOfHour(timestamp int64) int64 {
+	return time.Unix(timestamp, 0).Truncate(time.Hour).Unix()
+}
+
+// startOfDay returns the local midnight, like the days of the statistics
+func startOfDay(timestamp int64) int64 {
+	t := time.Unix(timestamp, 0)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Unix()
+}
+
+func percentile(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64{}, values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+	index := int(float64(len(sorted)-1) * p)
+	return sorted[index]
+}
@@ -0,0 +1,64 @@
+package crawler
+
+import (
+	"context"
+	"time"
+)
+
+// retryOptions control how often a failed check is repeated within one crawl
+type retryOptions struct {
+	// Attempts is the maximum number of checks per crawl
+	Attempts int `json:"attempts"`
+	// RetryDelay is the time to wait before the second attempt
+	RetryDelay time.Duration `json:"retryDelay"`
+	// RetryBackoff multiplies the delay after every further attempt
+	RetryBackoff float64 `json:"retryBackoff"`
+}
+
+const defaultRetryDelay = 2 * time.Second
+
+// merge returns a copy of options in which all values set in overrides are replaced
+func (options retryOptions) merge(overrides retryOptions) retryOptions {
+	if overrides.Attempts > 0 {
+		options.Attempts = overrides.Attempts
+	}
+	if overrides.RetryDelay > 0 {
+		options.RetryDelay = overrides.RetryDelay
+	}
+	if overrides.RetryBackoff > 0 {
+		options.RetryBackoff = overrides.RetryBackoff
+	}
+	return options
+}
+
+func (options retryOptions) getAttempts() int {
+	if options.Attempts <= 0 {
+		return 1
+	}
+	return options.Attempts
+}
+
+// getDelay returns the time to wait after the given attempt
+func (options retryOptions) getDelay(attempt int) time.Duration {
+	delay := options.RetryDelay
+	if delay <= 0 {
+		delay = defaultRetryDelay
+	}
+	for i := 1; i < attempt && options.RetryBackoff > 0; i++ {
+		delay = time.Duration(float64(delay) * options.RetryBackoff)
+	}
+	return delay
+}
+
+// sleep waits for duration and returns false if ctx is done before
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,101 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// flakyService fails until it was checked upAfter times
+type flakyService struct {
+	*portService
+	upAfter  int
+	attempts int
+}
+
+func (service *flakyService) crawl(context.Context) rawHistoricDataPoint {
+	service.attempts++
+	statusCode := portConnectionError
+	if service.upAfter > 0 && service.attempts >= service.upAfter {
+		statusCode = portOpen
+	}
+	return rawHistoricDataPoint{time.Now().Unix(), statusCode, 10, "", 1, nil}
+}
+
+func TestCrawlServiceRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		upAfter  int
+		expected int
+		up       bool
+	}{
+		{"single attempt by default", 0, 0, 1, false},
+		{"all attempts fail", 3, 0, 3, false},
+		{"second attempt succeeds", 3, 2, 2, true},
+		{"first attempt succeeds", 3, 1, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			service := &flakyService{portService: &portService{}, upAfter: test.upAfter}
+			service.retryOptions = retryOptions{Attempts: test.attempts, RetryDelay: time.Millisecond}
+
+			dataPoint := crawlService(context.Background(), service, crawlerConfig{})
+			if service.attempts != test.expected || dataPoint.GetAttempts() != test.expected {
+				t.Errorf("expected %d attempts, got %d checks and %d recorded", test.expected, service.attempts, dataPoint.GetAttempts())
+			}
+			if dataPoint.IsUp() != test.up {
+				t.Errorf("expected up to be %v", test.up)
+			}
+		})
+	}
+}
+
+func TestCrawlServiceStopsRetryingWhenCanceled(t *testing.T) {
+	service := &flakyService{portService: &portService{}}
+	service.retryOptions = retryOptions{Attempts: 3, RetryDelay: 10 * time.Second}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	dataPoint := crawlService(ctx, service, crawlerConfig{})
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the backoff to stop with the context, took %s", elapsed)
+	}
+	if service.attempts != 1 {
+		t.Errorf("expected no further attempt, got %d", service.attempts)
+	}
+	if dataPoint != nil {
+		t.Errorf("expected no data point of a canceled crawl, got %+v", dataPoint)
+	}
+}
+
+func TestRetryOptionsGetDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  retryOptions
+		attempt  int
+		expected time.Duration
+	}{
+		{"default delay", retryOptions{}, 1, defaultRetryDelay},
+		{"without backoff", retryOptions{RetryDelay: time.Second}, 3, time.Second},
+		{"first retry", retryOptions{RetryDelay: time.Second, RetryBackoff: 2}, 1, time.Second},
+		{"third retry", retryOptions{RetryDelay: time.Second, RetryBackoff: 2}, 3, 4 * time.Second},
+	}
+
+	for _, test := range tests {
+		if delay := test.options.getDelay(test.attempt); delay != test.expected {
+			t.Errorf("%s: expected %s, got %s", test.name, test.expected, delay)
+		}
+	}
+}
+
+func TestRetryOptionsMerge(t *testing.T) {
+	defaults := retryOptions{Attempts: 2, RetryDelay: time.Second, RetryBackoff: 2}
+	merged := defaults.merge(retryOptions{Attempts: 5})
+	if expected := (retryOptions{Attempts: 5, RetryDelay: time.Second, RetryBackoff: 2}); merged != expected {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+}
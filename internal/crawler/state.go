@@ -0,0 +1,121 @@
+package crawler
+
+// StateOptions control when a change of the state of a service is confirmed
+type StateOptions struct {
+	// FailureThreshold is the number of consecutive down or degraded data points after which the state changes
+	FailureThreshold int `json:"failureThreshold"`
+	// RecoveryThreshold is the number of consecutive up data points after which the service is up again
+	RecoveryThreshold int `json:"recoveryThreshold"`
+	// FlapWindow is the number of recent data points in which state changes are counted
+	FlapWindow int `json:"flapWindow"`
+	// FlapThreshold is the number of state changes within the window at which the service is flapping.
+	// The state of a flapping service is not changed until it settles.
+	FlapThreshold int `json:"flapThreshold"`
+}
+
+const (
+	StateUp       = "up"
+	StateDegraded = "degraded"
+	StateDown     = "down"
+)
+
+// GetState returns StateUp, StateDegraded or StateDown for a data point
+func GetState(dataPoint HistoricDataPoint) string {
+	if !dataPoint.IsUp() {
+		return StateDown
+	} else if dataPoint.IsDegraded() {
+		return StateDegraded
+	}
+	return StateUp
+}
+
+// GetStateTransitions returns all data points at which the confirmed state of the service changed
+func GetStateTransitions(historicData []HistoricDataPoint, options StateOptions) []HistoricDataPoint {
+	transitions, _ := confirmStates(historicData, options)
+	return transitions
+}
+
+// Merge returns a copy of options in which all values set in overrides are replaced
+func (options StateOptions) Merge(overrides StateOptions) StateOptions {
+	if overrides.FailureThreshold > 0 {
+		options.FailureThreshold = overrides.FailureThreshold
+	}
+	if overrides.RecoveryThreshold > 0 {
+		options.RecoveryThreshold = overrides.RecoveryThreshold
+	}
+	if overrides.FlapWindow > 0 {
+		options.FlapWindow = overrides.FlapWindow
+	}
+	if overrides.FlapThreshold > 0 {
+		options.FlapThreshold = overrides.FlapThreshold
+	}
+	return options
+}
+
+// confirmStates walks the historic data and returns the data points at which the confirmed state changed
+// together with the data point that confirmed the current state.
+// Disabled data points, those recorded during maintenance and rollups are skipped.
+func confirmStates(historicData []HistoricDataPoint, options StateOptions) ([]HistoricDataPoint, HistoricDataPoint) {
+	transitions := make([]HistoricDataPoint, 0)
+	states := make([]string, 0, len(historicData))
+	var current HistoricDataPoint
+	streak := 0
+
+	for _, dataPoint := range historicData {
+		if dataPoint.IsDisabled() || dataPoint.IsMaintenance() || dataPoint.GetRollup() != nil {
+			continue
+		}
+
+		state := GetState(dataPoint)
+		if len(states) > 0 && states[len(states)-1] == state {
+			streak++
+		} else {
+			streak = 1
+		}
+		states = append(states, state)
+
+		if current == nil {
+			current = dataPoint
+			continue
+		}
+		if state == GetState(current) || streak < options.getThreshold(state) || options.isFlapping(states) {
+			continue
+		}
+
+		current = dataPoint
+		transitions = append(transitions, dataPoint)
+	}
+
+	return transitions, current
+}
+
+func (options StateOptions) getThreshold(state string) int {
+	threshold := options.FailureThreshold
+	if state == StateUp {
+		threshold = options.RecoveryThreshold
+	}
+	if threshold <= 0 {
+		return 1
+	}
+	return threshold
+}
+
+// isFlapping counts the state changes within the last FlapWindow states
+func (options StateOptions) isFlapping(states []string) bool {
+	if options.FlapWindow <= 0 || options.FlapThreshold <= 0 {
+		return false
+	}
+
+	start := len(states) - options.FlapWindow
+	if start < 0 {
+		start = 0
+	}
+
+	changes := 0
+	for i := start + 1; i < len(states); i++ {
+		if states[i] != states[i-1] {
+			changes++
+		}
+	}
+	return changes >= options.FlapThreshold
+}
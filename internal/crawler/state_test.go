@@ -0,0 +1,74 @@
+package crawler
+
+import (
+	"reflect"
+	"testing"
+)
+
+// newStateTestData returns a data point per character, the timestamp is its index:
+// U is up, G degraded, D down, M maintenance and X disabled
+func newStateTestData(states string) []HistoricDataPoint {
+	service := &portService{}
+	statusCodes := map[rune]int{'U': portOpen, 'G': responseTooSlow, 'D': portConnectionError, 'M': maintenanceStatusCode, 'X': -1}
+	historicData := make([]HistoricDataPoint, 0, len(states))
+	for i, state := range states {
+		historicData = append(historicData, service.newDataPoint(rawHistoricDataPoint{int64(i), statusCodes[state], 10, "", 1, nil}))
+	}
+	return historicData
+}
+
+func TestConfirmStates(t *testing.T) {
+	tests := []struct {
+		name        string
+		states      string
+		options     StateOptions
+		transitions []int64
+		current     int64
+	}{
+		{"every change without thresholds", "UUDU", StateOptions{}, []int64{2, 3}, 3},
+		{"first data point is the initial state", "DDU", StateOptions{}, []int64{2}, 2},
+		{"degraded is a change", "UGU", StateOptions{}, []int64{1, 2}, 2},
+		{"failure threshold reached", "UDDUDDD", StateOptions{FailureThreshold: 3}, []int64{6}, 6},
+		{"failure threshold applies to degraded", "UGGU", StateOptions{FailureThreshold: 2}, []int64{2, 3}, 3},
+		{"history shorter than the threshold", "UDD", StateOptions{FailureThreshold: 3}, []int64{}, 0},
+		{"recovery threshold not reached", "UDDU", StateOptions{RecoveryThreshold: 2}, []int64{1}, 1},
+		{"recovery threshold reached", "UDDUU", StateOptions{RecoveryThreshold: 2}, []int64{1, 4}, 4},
+		{"maintenance and disabled are skipped", "UDMXD", StateOptions{FailureThreshold: 2}, []int64{4}, 4},
+		{"flapping keeps the state", "UUDUDUDU", StateOptions{FlapWindow: 4, FlapThreshold: 2}, []int64{2}, 2},
+		{"flapping ends when the state settles", "UUDUUUUU", StateOptions{FlapWindow: 4, FlapThreshold: 2}, []int64{2, 5}, 5},
+		{"changes outside the window do not count", "UDUUUUD", StateOptions{FlapWindow: 3, FlapThreshold: 2}, []int64{1, 3, 6}, 6},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transitions, current := confirmStates(newStateTestData(test.states), test.options)
+
+			timestamps := make([]int64, 0, len(transitions))
+			for _, transition := range transitions {
+				timestamps = append(timestamps, transition.GetTimestamp())
+			}
+			if !reflect.DeepEqual(timestamps, test.transitions) {
+				t.Errorf("expected transitions at %v, got %v", test.transitions, timestamps)
+			}
+			if current.GetTimestamp() != test.current {
+				t.Errorf("expected the current state from %d, got %d", test.current, current.GetTimestamp())
+			}
+		})
+	}
+}
+
+func TestConfirmStatesWithoutData(t *testing.T) {
+	transitions, current := confirmStates(newStateTestData("MX"), StateOptions{})
+	if len(transitions) != 0 || current != nil {
+		t.Errorf("expected no state, got %v and %v", transitions, current)
+	}
+}
+
+func TestStateOptionsMerge(t *testing.T) {
+	options := StateOptions{FailureThreshold: 2, RecoveryThreshold: 2, FlapWindow: 10, FlapThreshold: 4}
+	merged := options.Merge(StateOptions{FailureThreshold: 3})
+	expected := StateOptions{FailureThreshold: 3, RecoveryThreshold: 2, FlapWindow: 10, FlapThreshold: 4}
+	if merged != expected {
+		t.Errorf("expected %+v, got %+v", expected, merged)
+	}
+}
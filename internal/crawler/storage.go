@@ -0,0 +1,150 @@
+package crawler
+
+import (
+	"fmt"
+
+	"github.com/dorianim/downtimerobot/internal/safefile"
+	"github.com/goccy/go-json"
+)
+
+// StorageConfig selects the backend in which the historic data is kept
+type StorageConfig struct {
+	// Type is one of json, bbolt and append, the default is json
+	Type string `json:"type" validate:"oneof=json bbolt append"`
+	// Path is the file of the backend, every type has its own default
+	Path string `json:"path"`
+}
+
+// historicDataStorage loads and stores the historic data of all services keyed by service
+type historicDataStorage interface {
+	load() (rawHistoricData, error)
+	store(rawHistoricData) error
+	close() error
+}
+
+const (
+	jsonStorageType   = "json"
+	bboltStorageType  = "bbolt"
+	appendStorageType = "append"
+)
+
+// MigrateHistoricData copies all historic data from one backend to another.
+// It returns the number of migrated services.
+func MigrateHistoricData(from StorageConfig, to StorageConfig) (int, error) {
+	if from.getType() == to.getType() && from.getPath() == to.getPath() {
+		return 0, fmt.Errorf("Source and destination storage are the same")
+	}
+
+	source, err := openStorage(from)
+	if err != nil {
+		return 0, err
+	}
+	defer source.close()
+
+	destination, err := openStorage(to)
+	if err != nil {
+		return 0, err
+	}
+	defer destination.close()
+
+	data, err := source.load()
+	if err != nil {
+		return 0, err
+	}
+	return len(data), destination.store(data)
+}
+
+// RepairHistoricData loads the historic data of the configured storage, which recovers
+// corrupted data as far as possible, and stores it again. It returns the number of services.
+func RepairHistoricData() (int, error) {
+	conf, err := loadConfig()
+	if err != nil {
+		return 0, err
+	}
+
+	storage, err := openStorage(conf.Storage)
+	if err != nil {
+		return 0, err
+	}
+	defer storage.close()
+
+	data, err := storage.load()
+	if err != nil {
+		return 0, err
+	}
+	return len(data), storage.store(data)
+}
+
+// LoadStorageConfig returns the configured storage backend
+func LoadStorageConfig() (StorageConfig, error) {
+	conf, err := loadConfig()
+	if err != nil {
+		return StorageConfig{}, err
+	}
+	return conf.Storage, nil
+}
+
+func openStorage(conf StorageConfig) (historicDataStorage, error) {
+	switch conf.getType() {
+	case jsonStorageType:
+		return &jsonStorage{path: conf.getPath()}, nil
+	case bboltStorageType:
+		return openBboltStorage(conf.getPath())
+	case appendStorageType:
+		return &appendStorage{path: conf.getPath()}, nil
+	}
+	return nil, fmt.Errorf("Unknown storage type %s", conf.Type)
+}
+
+// == StorageConfig ==
+
+func (conf StorageConfig) getType() string {
+	if len(conf.Type) == 0 {
+		return jsonStorageType
+	}
+	return conf.Type
+}
+
+func (conf StorageConfig) getPath() string {
+	if len(conf.Path) > 0 {
+		return conf.Path
+	}
+
+	switch conf.getType() {
+	case bboltStorageType:
+		return "./historicData.db"
+	case appendStorageType:
+		return "./historicData.jsonl"
+	}
+	return historicDataFile
+}
+
+// == jsonStorage ==
+
+// jsonStorage keeps all historic data in one pretty printed JSON file
+type jsonStorage struct {
+	path string
+}
+
+// load restores the backup if the file is corrupted, see safefile.ReadFile
+func (storage *jsonStorage) load() (rawHistoricData, error) {
+	data := make(rawHistoricData)
+	err := safefile.ReadFile(storage.path, func(byteValue []byte) error {
+		decoded := make(rawHistoricData)
+		if err := json.Unmarshal(byteValue, &decoded); err != nil {
+			return err
+		}
+		data = decoded
+		return nil
+	})
+	return data, err
+}
+
+func (storage *jsonStorage) store(rawData rawHistoricData) error {
+	data, _ := json.MarshalIndent(rawData, "", " ")
+	return safefile.WriteFileWithBackup(storage.path, data, 0644)
+}
+
+func (storage *jsonStorage) close() error {
+	return nil
+}
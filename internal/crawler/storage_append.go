@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+
+	"github.com/dorianim/downtimerobot/internal/safefile"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+)
+
+// appendStorage keeps one compact JSON data point per line. New data points are appended to the file,
+// it is only rewritten if data points or services were removed or it contains corrupted lines.
+type appendStorage struct {
+	path string
+	// counts and timestamps are the number of data points and the latest timestamp in the file per service
+	counts     map[string]int
+	timestamps map[string]int64
+	corrupted  bool
+}
+
+type appendRecord struct {
+	Service string `json:"s"`
+	rawHistoricDataPoint
+}
+
+func (storage *appendStorage) load() (rawHistoricData, error) {
+	storage.counts = make(map[string]int)
+	storage.timestamps = make(map[string]int64)
+	storage.corrupted = false
+
+	file, err := os.Open(storage.path)
+	if err != nil && os.IsNotExist(err) {
+		return rawHistoricData{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data := make(rawHistoricData)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		// an interrupted append leaves a truncated line, it is dropped by the next rewrite
+		record := appendRecord{}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			log.WithFields(log.Fields{
+				"file": storage.path,
+				"err":  err.Error(),
+			}).Warn("Skipping corrupted line in historic data")
+			storage.corrupted = true
+			continue
+		}
+		data[record.Service] = append(data[record.Service], record.rawHistoricDataPoint)
+		storage.counts[record.Service]++
+		storage.timestamps[record.Service] = record.Timestamp
+	}
+	return data, scanner.Err()
+}
+
+func (storage *appendStorage) store(rawData rawHistoricData) error {
+	if storage.counts == nil {
+		if _, err := storage.load(); err != nil {
+			return err
+		}
+	}
+
+	if storage.needsCompaction(rawData) {
+		return storage.rewrite(rawData)
+	}
+
+	file, err := os.OpenFile(storage.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for key, dataPoints := range rawData {
+		for _, dataPoint := range storage.newDataPoints(key, dataPoints) {
+			if err := storage.write(writer, key, dataPoint); err != nil {
+				return err
+			}
+		}
+	}
+	return writer.Flush()
+}
+
+func (storage *appendStorage) close() error {
+	return nil
+}
+
+// needsCompaction returns true if the file contains data points which are not part of the data anymore
+func (storage *appendStorage) needsCompaction(rawData rawHistoricData) bool {
+	if storage.corrupted {
+		return true
+	}
+	for key, count := range storage.counts {
+		dataPoints, ok := rawData[key]
+		if !ok {
+			return true
+		}
+		if len(dataPoints)-len(storage.newDataPoints(key, dataPoints)) != count {
+			return true
+		}
+	}
+	return false
+}
+
+// newDataPoints returns the data points which are newer than the latest one in the file
+func (storage *appendStorage) newDataPoints(key string, dataPoints []rawHistoricDataPoint) []rawHistoricDataPoint {
+	last, ok := storage.timestamps[key]
+	if !ok {
+		return dataPoints
+	}
+
+	for i, dataPoint := range dataPoints {
+		if dataPoint.Timestamp > last {
+			return dataPoints[i:]
+		}
+	}
+	return nil
+}
+
+// rewrite replaces the file atomically and keeps the previous one as backup
+func (storage *appendStorage) rewrite(rawData rawHistoricData) error {
+	storage.counts = make(map[string]int)
+	storage.timestamps = make(map[string]int64)
+
+	var buffer bytes.Buffer
+	writer := bufio.NewWriter(&buffer)
+	for key, dataPoints := range rawData {
+		for _, dataPoint := range dataPoints {
+			if err := storage.write(writer, key, dataPoint); err != nil {
+				return err
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+
+	if err := safefile.WriteFileWithBackup(storage.path, buffer.Bytes(), 0644); err != nil {
+		return err
+	}
+	storage.corrupted = false
+	return nil
+}
+
+func (storage *appendStorage) write(writer *bufio.Writer, key string, dataPoint rawHistoricDataPoint) error {
+	line, err := json.Marshal(appendRecord{key, dataPoint})
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	storage.counts[key]++
+	storage.timestamps[key] = dataPoint.Timestamp
+	return nil
+}
@@ -0,0 +1,110 @@
+package crawler
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dorianim/downtimerobot/internal/safefile"
+)
+
+func newAppendTestData(timestamps ...int64) []rawHistoricDataPoint {
+	dataPoints := make([]rawHistoricDataPoint, 0, len(timestamps))
+	for _, timestamp := range timestamps {
+		dataPoints = append(dataPoints, rawHistoricDataPoint{timestamp, 200, 100, "", 1, nil})
+	}
+	return dataPoints
+}
+
+func TestAppendStorageRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "historicData.jsonl")
+	storage := &appendStorage{path: path}
+	if err := storage.store(rawHistoricData{"a": newAppendTestData(1, 2), "b": newAppendTestData(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// a new instance merges the data with the existing file and only appends the new data points
+	data := rawHistoricData{"a": newAppendTestData(1, 2, 3), "b": newAppendTestData(1, 2)}
+	if err := (&appendStorage{path: path}).store(data); err != nil {
+		t.Fatal(err)
+	}
+	assertLineCount(t, path, 5)
+	if _, err := os.Stat(safefile.BackupPath(path)); !os.IsNotExist(err) {
+		t.Error("expected the file to be appended to, not rewritten")
+	}
+
+	loaded, err := (&appendStorage{path: path}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, data) {
+		t.Errorf("expected %+v, got %+v", data, loaded)
+	}
+}
+
+func TestAppendStorageCompactsRemovedData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "historicData.jsonl")
+	storage := &appendStorage{path: path}
+	if err := storage.store(rawHistoricData{"a": newAppendTestData(1, 2, 3), "b": newAppendTestData(1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the retention removed a data point and the service b was removed
+	data := rawHistoricData{"a": newAppendTestData(2, 3, 4)}
+	if err := storage.store(data); err != nil {
+		t.Fatal(err)
+	}
+	assertLineCount(t, path, 3)
+	assertLineCount(t, safefile.BackupPath(path), 4)
+
+	loaded, err := (&appendStorage{path: path}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, data) {
+		t.Errorf("expected %+v, got %+v", data, loaded)
+	}
+}
+
+func TestAppendStorageDropsTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "historicData.jsonl")
+	content := `{"s":"a","t":1,"c":200,"r":100,"m":"","a":1}` + "\n" + `{"s":"a","t":2,"c":2`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := &appendStorage{path: path}
+	loaded, err := storage.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, rawHistoricData{"a": newAppendTestData(1)}) {
+		t.Errorf("expected the complete line only, got %+v", loaded)
+	}
+
+	// appending after the truncated line would corrupt the next data point, so the file is rewritten
+	data := rawHistoricData{"a": newAppendTestData(1, 3)}
+	if err := storage.store(data); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err = (&appendStorage{path: path}).load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, data) {
+		t.Errorf("expected %+v after the rewrite, got %+v", data, loaded)
+	}
+}
+
+func assertLineCount(t *testing.T, path string, expected int) {
+	t.Helper()
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(content, []byte("\n")); lines != expected {
+		t.Errorf("expected %d lines in %s, got %d", expected, path, lines)
+	}
+}
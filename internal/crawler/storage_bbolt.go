@@ -0,0 +1,133 @@
+package crawler
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/goccy/go-json"
+	bolt "go.etcd.io/bbolt"
+)
+
+// bboltStorage keeps the data points of every service in its own bucket keyed by timestamp,
+// only new data points and rollups are written on store
+type bboltStorage struct {
+	db *bolt.DB
+}
+
+const bboltLockTimeout = 10 * time.Second
+
+func openBboltStorage(path string) (*bboltStorage, error) {
+	// the database is locked while serve is running, other commands wait for it
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: bboltLockTimeout})
+	if err != nil {
+		return nil, err
+	}
+	return &bboltStorage{db: db}, nil
+}
+
+func (storage *bboltStorage) load() (rawHistoricData, error) {
+	data := make(rawHistoricData)
+	err := storage.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, bucket *bolt.Bucket) error {
+			dataPoints := make([]rawHistoricDataPoint, 0, bucket.Stats().KeyN)
+			err := bucket.ForEach(func(_ []byte, value []byte) error {
+				dataPoint := rawHistoricDataPoint{}
+				if err := json.Unmarshal(value, &dataPoint); err != nil {
+					return err
+				}
+				dataPoints = append(dataPoints, dataPoint)
+				return nil
+			})
+			data[string(name)] = dataPoints
+			return err
+		})
+	})
+	return data, err
+}
+
+func (storage *bboltStorage) store(rawData rawHistoricData) error {
+	return storage.db.Update(func(tx *bolt.Tx) error {
+		if err := deleteStaleBuckets(tx, rawData); err != nil {
+			return err
+		}
+
+		for key, dataPoints := range rawData {
+			bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+			if err != nil {
+				return err
+			}
+			if err := storeDataPointsInBucket(bucket, dataPoints); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (storage *bboltStorage) close() error {
+	return storage.db.Close()
+}
+
+// deleteStaleBuckets removes the buckets of services which are no longer part of the data
+func deleteStaleBuckets(tx *bolt.Tx, rawData rawHistoricData) error {
+	stale := make([][]byte, 0)
+	tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+		if _, ok := rawData[string(name)]; !ok {
+			stale = append(stale, append([]byte{}, name...))
+		}
+		return nil
+	})
+
+	for _, name := range stale {
+		if err := tx.DeleteBucket(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeDataPointsInBucket makes the bucket contain exactly the data points.
+// Raw data points never change, so only new ones are put. Rollups replace raw data points and
+// hourly rollups are merged into daily ones with the same timestamp, so they are always put.
+func storeDataPointsInBucket(bucket *bolt.Bucket, dataPoints []rawHistoricDataPoint) error {
+	keys := make(map[string]bool, len(dataPoints))
+	for _, dataPoint := range dataPoints {
+		keys[string(timestampToKey(dataPoint.Timestamp))] = true
+	}
+
+	// keys are deleted after the iteration, deleting with a cursor skips the following key
+	stale := make([][]byte, 0)
+	bucket.ForEach(func(key []byte, _ []byte) error {
+		if !keys[string(key)] {
+			stale = append(stale, append([]byte{}, key...))
+		}
+		return nil
+	})
+	for _, key := range stale {
+		if err := bucket.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	for _, dataPoint := range dataPoints {
+		key := timestampToKey(dataPoint.Timestamp)
+		if dataPoint.Rollup == nil && bucket.Get(key) != nil {
+			continue
+		}
+
+		value, err := json.Marshal(dataPoint)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func timestampToKey(timestamp int64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(timestamp))
+	return key
+}
@@ -0,0 +1,46 @@
+package crawler
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBboltStorageStoresRollups(t *testing.T) {
+	storage, err := openBboltStorage(filepath.Join(t.TempDir(), "historicData.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer storage.close()
+
+	now := time.Now()
+	dataPoints := make([]rawHistoricDataPoint, 0, 200)
+	for i := 199; i >= 0; i-- {
+		timestamp := now.Add(-time.Duration(i) * 10 * time.Minute).Unix()
+		dataPoints = append(dataPoints, rawHistoricDataPoint{timestamp, 200, 100, "", 1, nil})
+	}
+	if err := storage.store(rawHistoricData{"service": dataPoints}); err != nil {
+		t.Fatal(err)
+	}
+
+	service := &httpsService{}
+	options := RetentionOptions{Raw: 24 * time.Hour, Hourly: 25 * time.Hour}
+	for _, step := range []struct {
+		name string
+		now  time.Time
+	}{{"hourly rollups", now}, {"daily rollups", now.Add(2 * time.Hour)}} {
+		dataPoints = options.downsample(dataPoints, service, step.now)
+		if err := storage.store(rawHistoricData{"service": dataPoints}); err != nil {
+			t.Fatal(err)
+		}
+
+		loaded, err := storage.load()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded["service"], dataPoints) {
+			t.Errorf("%s: expected %d data points, got %d", step.name, len(dataPoints), len(loaded["service"]))
+		}
+	}
+}
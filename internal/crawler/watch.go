@@ -0,0 +1,132 @@
+package crawler
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxWatchSleep limits the time between two checks for due services
+const maxWatchSleep = time.Minute
+
+// crawlResult is the data point of one crawl of the service at index
+type crawlResult struct {
+	index     int
+	dataPoint HistoricDataPoint
+}
+
+// Watch crawls every service in its own interval until ctx is done.
+// After every batch of finished crawls the historic data is stored and handler is called with all services.
+// The services must not be used outside of handler, they are modified between its calls.
+// When ctx is done, running crawls are canceled and their results are stored before Watch returns.
+func Watch(ctx context.Context, handler func([]Service)) error {
+	conf, storage, services, err := openServices()
+	if err != nil {
+		return err
+	}
+	defer storage.close()
+
+	return watch(ctx, conf, storage, services, handler)
+}
+
+func watch(ctx context.Context, conf *config, storage historicDataStorage, services []Service, handler func([]Service)) error {
+	nextCrawl := make([]time.Time, len(services))
+	running := make([]bool, len(services))
+	runningCount := 0
+	results := make(chan crawlResult)
+	workers := make(chan struct{}, conf.Crawler.getWorkers())
+
+	log.WithFields(log.Fields{
+		"services": len(services),
+	}).Info("Watching services")
+
+	for {
+		now := time.Now()
+		wakeUp := now.Add(maxWatchSleep)
+		for i, service := range services {
+			if running[i] {
+				continue
+			}
+			if nextCrawl[i].After(now) {
+				if nextCrawl[i].Before(wakeUp) {
+					wakeUp = nextCrawl[i]
+				}
+				continue
+			}
+
+			running[i] = true
+			runningCount++
+			go func(index int, service Service) {
+				workers <- struct{}{}
+				dataPoint := crawlService(ctx, service, conf.Crawler)
+				<-workers
+				results <- crawlResult{index, dataPoint}
+			}(i, service)
+		}
+
+		select {
+		case result := <-results:
+			batch := collectCrawlResults(result, results)
+			for _, result := range batch {
+				running[result.index] = false
+				runningCount--
+				nextCrawl[result.index] = time.Now().Add(conf.Crawler.getInterval(services[result.index]))
+			}
+			if err := handleCrawlResults(batch, services, storage, conf); err != nil {
+				return err
+			}
+			if ctx.Err() == nil {
+				handler(services)
+			}
+
+		case <-time.After(time.Until(wakeUp)):
+
+		case <-ctx.Done():
+			log.WithFields(log.Fields{
+				"running": runningCount,
+			}).Info("Stopping, waiting for running crawls")
+
+			batch := make([]crawlResult, 0, runningCount)
+			for ; runningCount > 0; runningCount-- {
+				batch = append(batch, <-results)
+			}
+			return handleCrawlResults(batch, services, storage, conf)
+		}
+	}
+}
+
+// collectCrawlResults returns the first result together with all results which are already available
+func collectCrawlResults(first crawlResult, results chan crawlResult) []crawlResult {
+	batch := []crawlResult{first}
+	for {
+		select {
+		case result := <-results:
+			batch = append(batch, result)
+		default:
+			return batch
+		}
+	}
+}
+
+// handleCrawlResults appends the data points to their services and stores the historic data.
+// Crawls canceled on shutdown have no data point, they are not counted as crawl errors.
+func handleCrawlResults(batch []crawlResult, services []Service, storage historicDataStorage, conf *config) error {
+	crawledServices := make([]Service, 0, len(batch))
+	for _, result := range batch {
+		if result.dataPoint == nil {
+			continue
+		}
+		service := services[result.index]
+		service.appendHistoricData(result.dataPoint)
+		logDataPoint(service, result.dataPoint)
+		countCrawl(service, result.dataPoint)
+		crawledServices = append(crawledServices, service)
+	}
+
+	if len(crawledServices) == 0 {
+		return nil
+	}
+	applyRetention(crawledServices, conf.Retention)
+	return storeHistoricData(storage, services)
+}
@@ -0,0 +1,66 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// memoryStorage keeps the stored historic data in memory
+type memoryStorage struct {
+	data rawHistoricData
+}
+
+func (storage *memoryStorage) load() (rawHistoricData, error) {
+	return storage.data, nil
+}
+
+func (storage *memoryStorage) store(rawData rawHistoricData) error {
+	storage.data = rawData
+	return nil
+}
+
+func (storage *memoryStorage) close() error {
+	return nil
+}
+
+func TestWatchDropsChecksCanceledOnShutdown(t *testing.T) {
+	started := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	service := &httpsService{genericService: genericService{ID: "hanging"}, URL: server.URL}
+	storage := &memoryStorage{}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	handled := false
+	done := make(chan error, 1)
+	go func() {
+		done <- watch(ctx, &config{}, storage, []Service{service}, func([]Service) { handled = true })
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected watch to return after the shutdown")
+	}
+
+	if handled {
+		t.Error("expected the handler not to be called for a canceled check")
+	}
+	if len(service.GetHistoricData()) > 0 || len(storage.data["hanging"]) > 0 {
+		t.Errorf("expected no data point of the canceled check, got %+v", storage.data)
+	}
+}
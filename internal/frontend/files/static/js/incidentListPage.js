@@ -0,0 +1,23 @@
+document.addEventListener('alpine:init' , () => {
+    Alpine.store("api", {
+        incidentListEndpoint: "data/incidents.json",
+        incidentListEndpointData: null,
+        init() {
+            fetch(Alpine.store("api").incidentListEndpoint)
+            .then(response => response.json())
+            .then(data => this.incidentListEndpointData = data)
+        },
+    })
+})
+
+function incidentToTimeString(incident, timeZone) {
+    if(incident.ongoing) {
+        return `Since ${incident.startTimeString} (UTC${timeZone})`
+    }
+    return `${incident.startTimeString} - ${incident.endTimeString} (UTC${timeZone})`
+}
+
+function generateIncidentIcon(incident) {
+    const iconName = incident.ongoing ? "alert-triangle":"info"
+    return `<use xlink:href="static/img/symbol-defs.svg#icon-${iconName}"></use>`
+}
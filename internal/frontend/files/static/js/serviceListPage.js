@@ -31,9 +31,15 @@ function countStatisticsToErrorLevel(counts) {
     if(counts.down == counts.total){
         return 0; // Gray
     }
-    if(counts.down == 0) {
+    if(counts.down == 0 && counts.degraded == 0 && counts.maintenance == 0) {
         return 1; // Success
-    } 
+    }
+    if(counts.down == 0 && counts.degraded == 0) {
+        return 5; // Maintenance
+    }
+    if(counts.down == 0) {
+        return 4; // Degraded
+    }
     if(counts.down == counts.total - counts.disabled) {
         return 3; // Error
     }
@@ -42,18 +48,63 @@ function countStatisticsToErrorLevel(counts) {
 
 function countStatisticsToColorClass(counts) {
     level = countStatisticsToErrorLevel(counts);
-    return ["is-grey", "is-success", "is-warning", "is-error"][level]
+    return ["is-grey", "is-success", "is-warning", "is-error", "is-degraded", "is-maintenance"][level]
 }
 
 function countStatisticsToStatusMessage(counts) {
     level = countStatisticsToErrorLevel(counts);
-    return ["No services monitored", "All services operational", "Some services down", "All services down"][level]
+    return ["No services monitored", "All services operational", "Some services down", "All services down", "Some services degraded", "Some services under maintenance"][level]
+}
+
+function serviceToColorClass(service) {
+    if(service.disabled) {
+        return "is-grey"
+    }
+    if(service.maintenance) {
+        return "is-maintenance"
+    }
+    if(!service.up) {
+        return "is-error"
+    }
+    return service.degraded ? "is-degraded":"is-success"
+}
+
+function serviceToTextClass(service) {
+    if(service.disabled) {
+        return "uk-text-muted"
+    }
+    if(service.maintenance) {
+        return "uk-text-primary"
+    }
+    if(!service.up) {
+        return "uk-text-danger"
+    }
+    return service.degraded ? "uk-text-warning":"uk-text-primary"
 }
 
-function percentageToColor(percentage) {
+function serviceToStatusMessage(service) {
+    if(service.disabled) {
+        return "N/A"
+    }
+    if(service.maintenance) {
+        return "Maintenance"
+    }
+    if(!service.up) {
+        return "Down"
+    }
+    return service.degraded ? "Degraded":"Up"
+}
+
+function percentageToColor(percentage, degradation, maintenance) {
+    if(maintenance > 0 && (percentage < 0 || percentage >= 99)) {
+        return "#5b8def"
+    }
     if(percentage < 0) {
         return Alpine.store("siteData").darkMode ? "#687790":"#68779040"
     }
+    if(percentage >= 99 && degradation > 0) {
+        return "#e8c547"
+    }
     if(percentage < 95) {
         return "#df484a"
     }
@@ -66,7 +117,7 @@ function percentageToColor(percentage) {
     return "#3bd671"
 }
 
-function generateServiceUptimeChart(dailyStatistics, days) {
+function generateServiceUptimeChart(dailyStatistics, dailyDegradation, dailyMaintenance, days) {
 
     const svgHead = `<svg width="530" height="15" xmlns="http://www.w3.org/2000/svg" version="1.1" viewBox="0 0 530 15">`;
     let result = svgHead;
@@ -74,10 +125,14 @@ function generateServiceUptimeChart(dailyStatistics, days) {
 
     for(let i = 0; i < 90; i++) {
         percentage = dailyStatistics[89-i]
+        degradation = dailyDegradation[89-i]
+        maintenance = dailyMaintenance[89-i]
         let color
         Alpine.effect(() => {
-            color = percentageToColor(percentage*100)
+            color = percentageToColor(percentage*100, degradation, maintenance)
         })
+        let degradationText = degradation > 0 ? ` (${toPercent(degradation)} degraded)`:""
+        degradationText += maintenance > 0 ? ` (${toPercent(maintenance)} maintenance)`:""
         result += `<rect 
             height="15" 
             width="3.25" 
@@ -87,7 +142,7 @@ function generateServiceUptimeChart(dailyStatistics, days) {
             fill-opacity="1" 
             rx="1.625"
             ry="1.625"
-            uk-tooltip="<div class='uk-text-muted font-12'>${days[89-i]}</div>${toPercent(percentage)}" 
+            uk-tooltip="<div class='uk-text-muted font-12'>${days[89-i]}</div>${toPercent(percentage)}${degradationText}" 
             aria-expanded="false"
         >
         </rect>`
@@ -14,6 +14,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/dorianim/downtimerobot/internal/announcements"
+	"github.com/dorianim/downtimerobot/internal/safefile"
 	"github.com/dorianim/downtimerobot/internal/statistics"
 	"github.com/dorianim/downtimerobot/internal/templates"
 	"github.com/leaanthony/debme"
@@ -37,7 +38,7 @@ type frontendConfig struct {
 }
 
 // Generate the static files for the frontend
-func Generate(serviceList statistics.ServiceList, serviceDetailList []statistics.ServiceDetails, announcementList *announcements.Announcements) error {
+func Generate(serviceList statistics.ServiceList, serviceDetailList []statistics.ServiceDetails, announcementList *announcements.Announcements, incidentList statistics.IncidentList) error {
 	files, _ := debme.FS(frontendFiles, "files")
 	templates, _ := files.FS("templates")
 	staticFiles, _ := files.FS("static")
@@ -60,6 +61,9 @@ func Generate(serviceList statistics.ServiceList, serviceDetailList []statistics
 	if err := storeAnnouncementList(announcementList); err != nil {
 		return err
 	}
+	if err := storeIncidentList(incidentList); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -121,11 +125,11 @@ func copyStaticFiles(staticFiles debme.Debme) error {
 			return minifyAndWriteFile("static/"+path, string(b))
 		}
 
-		destinationPath := getDestinationPath("static/" + path)
-		if err = os.MkdirAll(filepath.Dir(destinationPath), 0777); err != nil {
+		b, err := fs.ReadFile(staticFiles, path)
+		if err != nil {
 			return err
 		}
-		return staticFiles.CopyFile(path, destinationPath, 0666)
+		return writeFile("static/"+path, b)
 	})
 }
 
@@ -146,8 +150,7 @@ func writeFile(path string, content []byte) error {
 		return err
 	}
 
-	err := os.WriteFile(destinationPath, content, 0666)
-	return err
+	return safefile.WriteFile(destinationPath, content, 0666)
 }
 
 func getDestinationPath(sourcePath string) string {
@@ -162,7 +165,7 @@ func storeServiceList(serviceList statistics.ServiceList) error {
 func storeServiceDetailList(serviceDetailList []statistics.ServiceDetails) error {
 	for _, serviceDetails := range serviceDetailList {
 		data, _ := json.MarshalIndent(serviceDetails, "", " ")
-		if err := writeFile("data/"+serviceDetails.Service.Host+".json", data); err != nil {
+		if err := writeFile("data/"+serviceDetails.Service.ID+".json", data); err != nil {
 			return err
 		}
 	}
@@ -174,6 +177,16 @@ func storeAnnouncementList(announcementList *announcements.Announcements) error
 	return writeFile("data/announcementList.json", data)
 }
 
+func storeIncidentList(incidentList statistics.IncidentList) error {
+	data, _ := json.MarshalIndent(incidentList, "", " ")
+	return writeFile("data/incidents.json", data)
+}
+
+// EmptyConfig returns the frontend config for the schema
+func EmptyConfig() interface{} {
+	return &config{}
+}
+
 func loadConfig() (*frontendConfig, error) {
 	conf := &config{}
 	if err := viper.Unmarshal(conf); err != nil {
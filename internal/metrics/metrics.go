@@ -0,0 +1,238 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dorianim/downtimerobot/internal/crawler"
+	"github.com/dorianim/downtimerobot/internal/safefile"
+	"github.com/dorianim/downtimerobot/internal/statistics"
+	"github.com/spf13/viper"
+)
+
+type config struct {
+	Metrics metricsConfig `json:"metrics"`
+}
+
+type metricsConfig struct {
+	// Textfile is written after every run, e.g. for the textfile collector of the node exporter.
+	// It only contains the gauges, the counters and the check duration histogram are served by serve.
+	Textfile string `json:"textfile"`
+}
+
+// Generate returns the gauges of the services in the Prometheus text format, they are also written to the textfile.
+// Values derived from the historic data decrease when old data points are removed, so there are no counters.
+func Generate(crawledServices []crawler.Service, serviceList statistics.ServiceList) []byte {
+	uptimes := make(map[string]statistics.UptimeStatistics)
+	for _, service := range serviceList.Services {
+		uptimes[service.ID] = service.Uptime
+	}
+
+	buf := new(bytes.Buffer)
+
+	writeHeader(buf, "downtimerobot_service_up", "gauge", "Whether the service is up (1) or down (0)")
+	for _, service := range crawledServices {
+		if !service.IsDisabled() {
+			writeSample(buf, "downtimerobot_service_up", serviceLabels(service), boolToFloat(service.IsUp()))
+		}
+	}
+
+	writeHeader(buf, "downtimerobot_service_degraded", "gauge", "Whether the service is degraded (1) or not (0)")
+	for _, service := range crawledServices {
+		if !service.IsDisabled() {
+			writeSample(buf, "downtimerobot_service_degraded", serviceLabels(service), boolToFloat(service.IsDegraded()))
+		}
+	}
+
+	writeHeader(buf, "downtimerobot_service_response_time_seconds", "gauge", "Response time of the last check")
+	for _, service := range crawledServices {
+		if dataPoint := getLastDataPoint(service); dataPoint != nil && dataPoint.GetResponseTime() >= 0 {
+			writeSample(buf, "downtimerobot_service_response_time_seconds", serviceLabels(service), float64(dataPoint.GetResponseTime())/1000)
+		}
+	}
+
+	writeHeader(buf, "downtimerobot_service_last_check_timestamp_seconds", "gauge", "Time of the last check")
+	for _, service := range crawledServices {
+		if dataPoint := getLastDataPoint(service); dataPoint != nil {
+			writeSample(buf, "downtimerobot_service_last_check_timestamp_seconds", serviceLabels(service), float64(dataPoint.GetTimestamp()))
+		}
+	}
+
+	writeHeader(buf, "downtimerobot_service_retained_check_failures", "gauge", "Number of failed checks in the retained historic data")
+	for _, service := range crawledServices {
+		writeSample(buf, "downtimerobot_service_retained_check_failures", serviceLabels(service), float64(countFailures(service)))
+	}
+
+	writeHeader(buf, "downtimerobot_service_crawl_error", "gauge", "Whether the last crawl did not check the service before the deadline (1) or did (0)")
+	for _, service := range crawledServices {
+		if counters, ok := crawler.GetCrawlCounters(service.GetID()); ok {
+			writeSample(buf, "downtimerobot_service_crawl_error", serviceLabels(service), boolToFloat(counters.LastError))
+		}
+	}
+
+	writeHeader(buf, "downtimerobot_service_uptime_ratio", "gauge", "Uptime of the last days, like on the status page")
+	for _, service := range crawledServices {
+		uptime, ok := uptimes[service.GetID()]
+		if !ok || service.IsDisabled() {
+			continue
+		}
+		for _, days := range []struct {
+			days  string
+			value float32
+		}{{"1", uptime.OneDay}, {"7", uptime.SevenDays}, {"30", uptime.ThirtyDays}, {"90", uptime.NinetyDays}} {
+			if days.value >= 0 {
+				writeSample(buf, "downtimerobot_service_uptime_ratio", serviceLabels(service)+`,days="`+days.days+`"`, float64(days.value))
+			}
+		}
+	}
+
+	writeHeader(buf, "downtimerobot_service_certificate_expiry_timestamp_seconds", "gauge", "Expiry of the certificate presented in the last check")
+	for _, service := range crawledServices {
+		if certificateService, ok := service.(crawler.CertificateService); ok {
+			if certificate, ok := certificateService.GetCertificate(); ok {
+				writeSample(buf, "downtimerobot_service_certificate_expiry_timestamp_seconds", serviceLabels(service), float64(certificate.NotAfter.Unix()))
+			}
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// GenerateCounters returns the counters of the crawls since the start in the Prometheus text format.
+// They are only meaningful in serve mode, a single run would start them over, so they are not written to the textfile.
+func GenerateCounters(crawledServices []crawler.Service) []byte {
+	counters := make(map[string]crawler.CrawlCounters)
+	for _, service := range crawledServices {
+		if serviceCounters, ok := crawler.GetCrawlCounters(service.GetID()); ok {
+			counters[service.GetID()] = serviceCounters
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	for _, counter := range []struct {
+		name  string
+		help  string
+		value func(crawler.CrawlCounters) int
+	}{
+		{"downtimerobot_service_checks_total", "Number of checks since the start", func(c crawler.CrawlCounters) int { return c.Checks }},
+		{"downtimerobot_service_check_failures_total", "Number of failed checks since the start", func(c crawler.CrawlCounters) int { return c.Failures }},
+		{"downtimerobot_service_crawl_errors_total", "Number of crawls which did not check the service before the deadline", func(c crawler.CrawlCounters) int { return c.Errors }},
+	} {
+		writeHeader(buf, counter.name, "counter", counter.help)
+		for _, service := range crawledServices {
+			if serviceCounters, ok := counters[service.GetID()]; ok {
+				writeSample(buf, counter.name, serviceLabels(service), float64(counter.value(serviceCounters)))
+			}
+		}
+	}
+
+	writeHeader(buf, "downtimerobot_service_check_duration_seconds", "histogram", "Duration of the checks since the start")
+	for _, service := range crawledServices {
+		if serviceCounters, ok := counters[service.GetID()]; ok {
+			writeCheckDurationHistogram(buf, service, serviceCounters)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// WriteTextfile writes the metrics to the configured textfile, if there is one
+func WriteTextfile(content []byte) error {
+	conf, err := loadConfig()
+	if err != nil {
+		return err
+	}
+	if len(conf.Metrics.Textfile) == 0 {
+		return nil
+	}
+	return safefile.WriteFile(conf.Metrics.Textfile, content, 0644)
+}
+
+func writeCheckDurationHistogram(buf *bytes.Buffer, service crawler.Service, counters crawler.CrawlCounters) {
+	labels := serviceLabels(service)
+	for i, bucket := range crawler.CheckDurationBuckets {
+		writeSample(buf, "downtimerobot_service_check_duration_seconds_bucket", fmt.Sprintf(`%s,le="%g"`, labels, bucket), float64(counters.DurationBuckets[i]))
+	}
+	writeSample(buf, "downtimerobot_service_check_duration_seconds_bucket", labels+`,le="+Inf"`, float64(counters.DurationCount))
+	writeSample(buf, "downtimerobot_service_check_duration_seconds_sum", labels, counters.DurationSum)
+	writeSample(buf, "downtimerobot_service_check_duration_seconds_count", labels, float64(counters.DurationCount))
+}
+
+// countFailures counts the down data points, including those aggregated in rollups
+func countFailures(service crawler.Service) int {
+	failures := 0
+	for _, dataPoint := range service.GetHistoricData() {
+		if rollup := dataPoint.GetRollup(); rollup != nil {
+			failures += rollup.Down
+		} else if !dataPoint.IsUp() && !dataPoint.IsDisabled() && !dataPoint.IsMaintenance() {
+			failures++
+		}
+	}
+	return failures
+}
+
+func getLastDataPoint(service crawler.Service) crawler.HistoricDataPoint {
+	historicData := service.GetHistoricData()
+	if len(historicData) == 0 || historicData[len(historicData)-1].GetRollup() != nil {
+		return nil
+	}
+	return historicData[len(historicData)-1]
+}
+
+// == exposition format ==
+
+func writeHeader(buf *bytes.Buffer, name string, metricType string, help string) {
+	fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func writeSample(buf *bytes.Buffer, name string, labels string, value float64) {
+	fmt.Fprintf(buf, "%s{%s} %s\n", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+}
+
+func serviceLabels(service crawler.Service) string {
+	labels := map[string]string{
+		"id":   service.GetID(),
+		"name": service.GetName(),
+		"type": service.GetType(),
+		"host": service.GetHost(),
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, escapeLabelValue(labels[name]))
+	}
+	return strings.Join(pairs, ",")
+}
+
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func boolToFloat(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// EmptyConfig returns the metrics config for the schema
+func EmptyConfig() interface{} {
+	return &config{}
+}
+
+func loadConfig() (*config, error) {
+	conf := &config{}
+	if err := viper.Unmarshal(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
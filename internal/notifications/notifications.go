@@ -2,9 +2,11 @@ package notifications
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"text/template"
 
+	"github.com/containrrr/shoutrrr"
 	"github.com/dorianim/downtimerobot/internal/crawler"
 	"github.com/dorianim/downtimerobot/internal/templates"
 	log "github.com/sirupsen/logrus"
@@ -16,15 +18,23 @@ type notificationConfig struct {
 }
 
 type notificationTarget struct {
-	Enabled         bool   `json:"enabled"`
-	Name            string `json:"name"`
-	Template        string `json:"template"`
+	// Enabled is true if it is missing, targets were always enabled before it was checked
+	Enabled  *bool  `json:"enabled"`
+	Name     string `json:"name"`
+	Template string `json:"template" validate:"template"`
+	// ShoutrrrURL may reference environment variables like ${SLACK_TOKEN}
 	ShoutrrrURL     string `json:"shoutrrrUrl"`
-	ServicesPattern string `json:"servicesPattern"`
+	ServicesPattern string `json:"servicesPattern" validate:"regexp"`
+	// FailOnError makes the command fail if a notification could not be sent to this target
+	FailOnError bool `json:"failOnError"`
+	// StateOptions override the ones of the services for this target
+	crawler.StateOptions `mapstructure:",squash"`
 }
 
 var config *notificationConfig = nil
+var state notificationState = nil
 
+// Notify sends all state transitions of the services which were not yet announced to their targets
 func Notify(crawledServices []crawler.Service) error {
 	var err error
 	config, err = loadConfig()
@@ -32,59 +42,120 @@ func Notify(crawledServices []crawler.Service) error {
 		return err
 	}
 
-	return notifyServices(crawledServices)
+	state, err = loadNotificationState()
+	if err != nil {
+		return err
+	}
+
+	notifyErr := notifyServices(crawledServices)
+	if err := storeNotificationState(state); err != nil {
+		return err
+	}
+	return notifyErr
+}
+
+// RepairNotificationState recovers a corrupted notification state as far as possible
+func RepairNotificationState() error {
+	state, err := loadNotificationState()
+	if err != nil {
+		return err
+	}
+	return storeNotificationState(state)
 }
 
 func notifyServices(services []crawler.Service) error {
+	failed := 0
 	for _, service := range services {
 		if err := notifyService(service); err != nil {
 			log.WithFields(log.Fields{
-				"service": service.GetHost(),
+				"service": service.GetID(),
+				"host":    service.GetHost(),
 				"err":     err.Error(),
 			}).Error("Error sending notification to service")
+			failed++
 		}
 	}
-	return nil
-}
-
-func notifyService(service crawler.Service) error {
-	historicData := service.GetHistoricData()
-	historicDataLength := len(historicData)
 
-	if len(historicData) >= 2 && historicData[historicDataLength-1].IsUp() != historicData[historicDataLength-2].IsUp() {
-		log.WithFields(log.Fields{
-			"service": service,
-		}).Debug("Service changed state")
-		return sendNotificationForService(service)
+	if failed > 0 {
+		return fmt.Errorf("Failed to send notifications for %d services", failed)
 	}
-
 	return nil
 }
 
-func sendNotificationForService(service crawler.Service) error {
+func notifyService(service crawler.Service) error {
 	targets, err := getNotificationTargetsForService(service)
 	if err != nil {
 		return err
 	}
 
+	var result error
 	for _, target := range targets {
-		if err := sendNotificationForServiceToTarget(service, target); err != nil {
+		options := service.GetStateOptions().Merge(target.StateOptions)
+		transitions := crawler.GetStateTransitions(service.GetHistoricData(), options)
+		if err := notifyServiceToTarget(service, target, transitions); err != nil {
 			log.WithFields(log.Fields{
-				"service":            service.GetHost(),
+				"service":            service.GetID(),
+				"host":               service.GetHost(),
 				"notificationTarget": target.Name,
 				"err":                err.Error(),
 			}).Error("Error sending notification to target")
+
+			if target.FailOnError {
+				result = fmt.Errorf("Error sending notification to target %s: %s", target.Name, err.Error())
+			}
+		}
+	}
+
+	return result
+}
+
+// notifyServiceToTarget sends all transitions newer than the last one announced to the target.
+// If nothing was announced to the target yet, only a transition in the latest crawl is sent.
+func notifyServiceToTarget(service crawler.Service, target notificationTarget, transitions []crawler.HistoricDataPoint) error {
+	lastAnnounced, ok := state.get(service, target)
+	if !ok {
+		historicData := service.GetHistoricData()
+		historicDataLength := len(historicData)
+		if historicDataLength == 0 {
+			return nil
+		}
+
+		baseline := historicData[historicDataLength-1]
+		if historicDataLength >= 2 {
+			baseline = historicData[historicDataLength-2]
 		}
+		lastAnnounced = announcedTransition{baseline.GetTimestamp(), crawler.GetState(baseline)}
+		state.set(service, target, lastAnnounced)
+	}
+
+	for _, transition := range transitions {
+		if transition.GetTimestamp() <= lastAnnounced.Timestamp {
+			continue
+		}
+
+		log.WithFields(log.Fields{
+			"service": service.GetID(),
+			"host":    service.GetHost(),
+			"state":   crawler.GetState(transition),
+		}).Debug("Service changed state")
+
+		if err := sendNotificationForServiceToTarget(service, target, transition); err != nil {
+			return err
+		}
+
+		lastAnnounced = announcedTransition{transition.GetTimestamp(), crawler.GetState(transition)}
+		state.set(service, target, lastAnnounced)
 	}
 
 	return nil
 }
 
-func sendNotificationForServiceToTarget(service crawler.Service, target notificationTarget) error {
+func sendNotificationForServiceToTarget(service crawler.Service, target notificationTarget, transition crawler.HistoricDataPoint) error {
 	parsedTemplate, err := template.New("t").Parse(target.Template)
 	if err != nil {
 		log.WithFields(log.Fields{
-			"service":            service.GetHost(),
+			"service":            service.GetID(),
+			"host":               service.GetHost(),
 			"notificationTarget": target.Name,
 			"template":           target.Template,
 			"err":                err.Error(),
@@ -92,25 +163,41 @@ func sendNotificationForServiceToTarget(service crawler.Service, target notifica
 		return errors.New("Error parsing template")
 	}
 
-	service.IsUp()
 	executedTemplate, err := templates.ExecuteTemplate(parsedTemplate, map[string]interface{}{
-		"Service": service,
-		"Target":  target,
+		"Service":   service,
+		"Target":    target,
+		"DataPoint": transition,
+		"State":     crawler.GetState(transition),
 	})
+	if err != nil {
+		return err
+	}
 
-	//return shoutrrr.Send(target.ShoutrrrURL, executedTemplate)
+	log.WithFields(log.Fields{
+		"service":            service.GetID(),
+		"host":               service.GetHost(),
+		"notificationTarget": target.Name,
+	}).Info("Sending notification")
 
-	log.Info("Sending: " + executedTemplate)
-	return nil
+	return shoutrrr.Send(crawler.ExpandEnv(target.ShoutrrrURL), executedTemplate)
+}
+
+func (target notificationTarget) isEnabled() bool {
+	return target.Enabled == nil || *target.Enabled
 }
 
 func getNotificationTargetsForService(service crawler.Service) ([]notificationTarget, error) {
 	targets := make([]notificationTarget, 0)
 	for _, target := range config.NotificationTargets {
+		if !target.isEnabled() {
+			continue
+		}
+
 		matched, err := regexp.Match(target.ServicesPattern, []byte(service.GetName()))
 		if err != nil {
 			log.WithFields(log.Fields{
-				"service":            service.GetName(),
+				"service":            service.GetID(),
+				"name":               service.GetName(),
 				"notificationTarget": target.Name,
 				"servicesPattern":    target.ServicesPattern,
 				"err":                err,
@@ -122,6 +209,11 @@ func getNotificationTargetsForService(service crawler.Service) ([]notificationTa
 	return targets, nil
 }
 
+// EmptyConfig returns the notification config for the schema
+func EmptyConfig() interface{} {
+	return &notificationConfig{}
+}
+
 func loadConfig() (*notificationConfig, error) {
 	conf := &notificationConfig{}
 	if err := viper.Unmarshal(conf); err != nil {
@@ -0,0 +1,156 @@
+package notifications
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dorianim/downtimerobot/internal/crawler"
+)
+
+// wentDownService was up in the check before its last one, which failed
+type wentDownService struct {
+	crawler.Service
+	lastCheck int64
+}
+
+func (service wentDownService) GetID() string                         { return "test" }
+func (service wentDownService) GetName() string                       { return "Test" }
+func (service wentDownService) GetHost() string                       { return "test.example" }
+func (service wentDownService) GetStateOptions() crawler.StateOptions { return crawler.StateOptions{} }
+
+func (service wentDownService) GetHistoricData() []crawler.HistoricDataPoint {
+	return []crawler.HistoricDataPoint{
+		checkResult{timestamp: service.lastCheck - 60, up: true},
+		checkResult{timestamp: service.lastCheck, up: false},
+	}
+}
+
+type checkResult struct {
+	crawler.HistoricDataPoint
+	timestamp int64
+	up        bool
+}
+
+func (dataPoint checkResult) IsUp() bool                 { return dataPoint.up }
+func (dataPoint checkResult) IsDegraded() bool           { return false }
+func (dataPoint checkResult) IsDisabled() bool           { return false }
+func (dataPoint checkResult) IsMaintenance() bool        { return false }
+func (dataPoint checkResult) GetTimestamp() int64        { return dataPoint.timestamp }
+func (dataPoint checkResult) GetRollup() *crawler.Rollup { return nil }
+
+// webhookStandIn records the bodies and paths of the requests it receives and answers with status
+type webhookStandIn struct {
+	*httptest.Server
+	bodies []string
+	paths  []string
+}
+
+func newWebhookStandIn(t *testing.T, status int) *webhookStandIn {
+	standIn := &webhookStandIn{}
+	standIn.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		standIn.bodies = append(standIn.bodies, string(body))
+		standIn.paths = append(standIn.paths, r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(standIn.Close)
+	return standIn
+}
+
+// shoutrrrURL returns a generic webhook URL of the stand-in
+func (standIn *webhookStandIn) shoutrrrURL() string {
+	return "generic://" + strings.TrimPrefix(standIn.URL, "http://") + "/hook?disabletls=yes"
+}
+
+// setUp replaces the config and the state of the package and returns a service which just went down
+func setUp(targets ...notificationTarget) []crawler.Service {
+	config = &notificationConfig{NotificationTargets: targets}
+	state = make(notificationState)
+
+	return []crawler.Service{wentDownService{lastCheck: time.Now().Unix()}}
+}
+
+func TestNotifyDeliversToTarget(t *testing.T) {
+	standIn := newWebhookStandIn(t, http.StatusOK)
+	services := setUp(notificationTarget{
+		Name:        "webhook",
+		Template:    "{{ .Service.GetName }} is {{ .State }}",
+		ShoutrrrURL: standIn.shoutrrrURL(),
+	})
+
+	if err := notifyServices(services); err != nil {
+		t.Fatal(err)
+	}
+	if len(standIn.bodies) != 1 || standIn.bodies[0] != "Test is down" {
+		t.Errorf("expected one notification \"Test is down\", got %q", standIn.bodies)
+	}
+
+	// the transition was announced, so it is not sent again
+	if err := notifyServices(services); err != nil {
+		t.Fatal(err)
+	}
+	if len(standIn.bodies) != 1 {
+		t.Errorf("expected no further notification, got %q", standIn.bodies)
+	}
+}
+
+func TestNotifyFailOnError(t *testing.T) {
+	standIn := newWebhookStandIn(t, http.StatusInternalServerError)
+
+	for _, failOnError := range []bool{false, true} {
+		services := setUp(notificationTarget{
+			Name:        "webhook",
+			Template:    "{{ .State }}",
+			ShoutrrrURL: standIn.shoutrrrURL(),
+			FailOnError: failOnError,
+		})
+
+		err := notifyServices(services)
+		if failOnError && err == nil {
+			t.Error("expected an error with failOnError")
+		} else if !failOnError && err != nil {
+			t.Errorf("expected no error without failOnError, got %s", err.Error())
+		}
+	}
+}
+
+func TestNotifyExpandsEnvironmentVariablesInShoutrrrURL(t *testing.T) {
+	standIn := newWebhookStandIn(t, http.StatusOK)
+	t.Setenv("DOWNTIMEROBOT_TEST_WEBHOOK", strings.TrimPrefix(standIn.URL, "http://"))
+	services := setUp(notificationTarget{
+		Name:        "webhook",
+		Template:    "{{ .State }}",
+		ShoutrrrURL: "generic://${DOWNTIMEROBOT_TEST_WEBHOOK}/hook$secret?disabletls=yes",
+		FailOnError: true,
+	})
+
+	if err := notifyServices(services); err != nil {
+		t.Fatal(err)
+	}
+	// a literal $ like in a password is kept
+	if len(standIn.paths) != 1 || standIn.paths[0] != "/hook$secret" {
+		t.Errorf("expected one notification to /hook$secret, got %q", standIn.paths)
+	}
+}
+
+func TestNotifySkipsDisabledTargets(t *testing.T) {
+	standIn := newWebhookStandIn(t, http.StatusOK)
+	disabled := false
+	services := setUp(notificationTarget{
+		Enabled:     &disabled,
+		Name:        "webhook",
+		Template:    "{{ .State }}",
+		ShoutrrrURL: standIn.shoutrrrURL(),
+	})
+
+	if err := notifyServices(services); err != nil {
+		t.Fatal(err)
+	}
+	if len(standIn.bodies) != 0 {
+		t.Errorf("expected no notification to a disabled target, got %q", standIn.bodies)
+	}
+}
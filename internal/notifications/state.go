@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"github.com/dorianim/downtimerobot/internal/crawler"
+	"github.com/dorianim/downtimerobot/internal/safefile"
+	"github.com/goccy/go-json"
+)
+
+// notificationState maps service ids to the last transition announced to each of their targets.
+// Entries of older versions are keyed by host.
+type notificationState map[string]map[string]announcedTransition
+
+// announcedTransition is the data point of a state transition which was sent to a target
+type announcedTransition struct {
+	Timestamp int64  `json:"t"`
+	State     string `json:"s"`
+}
+
+const notificationStateFile = "./notificationState.json"
+
+func loadNotificationState() (notificationState, error) {
+	state := make(notificationState)
+	err := safefile.ReadFile(notificationStateFile, func(data []byte) error {
+		decoded := make(notificationState)
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return err
+		}
+		state = decoded
+		return nil
+	})
+	return state, err
+}
+
+func storeNotificationState(state notificationState) error {
+	data, _ := json.MarshalIndent(state, "", " ")
+	return safefile.WriteFileWithBackup(notificationStateFile, data, 0644)
+}
+
+func (state notificationState) get(service crawler.Service, target notificationTarget) (announcedTransition, bool) {
+	if transitions, ok := state[service.GetID()]; ok {
+		transition, ok := transitions[target.Name]
+		return transition, ok
+	}
+	transition, ok := state[service.GetHost()][target.Name]
+	return transition, ok
+}
+
+func (state notificationState) set(service crawler.Service, target notificationTarget, transition announcedTransition) {
+	if _, ok := state[service.GetID()]; !ok {
+		state[service.GetID()] = make(map[string]announcedTransition)
+	}
+	state[service.GetID()][target.Name] = transition
+}
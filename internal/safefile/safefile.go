@@ -0,0 +1,148 @@
+package safefile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WriteFile writes the data to a temporary file next to path and renames it,
+// so path contains either the old or the new data but never a part of it
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	// the file is created with perm instead of using os.CreateTemp to respect the umask
+	tmpPath := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.tmp-%d-%d", filepath.Base(path), os.Getpid(), time.Now().UnixNano()))
+	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, perm)
+	if err != nil {
+		return err
+	}
+
+	if err := writeAndSync(tmpFile, data); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// WriteFileWithBackup works like WriteFile but keeps the previous content as backup, see ReadFile
+func WriteFileWithBackup(path string, data []byte, perm os.FileMode) error {
+	if err := backup(path); err != nil {
+		return err
+	}
+	return WriteFile(path, data, perm)
+}
+
+// ReadFile passes the content of path to decode. If decode fails, the corrupted file
+// is moved aside and the backup is decoded instead. If there is no usable backup either,
+// decode is not called again and the caller has to start from scratch.
+// A missing file is no error, decode is not called then.
+func ReadFile(path string, decode func([]byte) error) error {
+	data, err := os.ReadFile(path)
+	if err != nil && os.IsNotExist(err) {
+		return readBackup(path, decode)
+	} else if err != nil {
+		return err
+	}
+
+	decodeErr := decode(data)
+	if decodeErr == nil {
+		return nil
+	}
+
+	corruptedPath := path + ".corrupted"
+	log.WithFields(log.Fields{
+		"file":    path,
+		"movedTo": corruptedPath,
+		"err":     decodeErr.Error(),
+	}).Warn("File is corrupted, restoring the backup")
+
+	if err := os.Rename(path, corruptedPath); err != nil {
+		return err
+	}
+	return readBackup(path, decode)
+}
+
+// BackupPath returns the path of the backup of path
+func BackupPath(path string) string {
+	return path + ".bak"
+}
+
+func readBackup(path string, decode func([]byte) error) error {
+	data, err := os.ReadFile(BackupPath(path))
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if err := decode(data); err != nil {
+		log.WithFields(log.Fields{
+			"file": BackupPath(path),
+			"err":  err.Error(),
+		}).Error("Backup is corrupted as well, starting from scratch")
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"file": BackupPath(path),
+	}).Info("Restored backup")
+	return nil
+}
+
+// backup replaces the backup of path with its current content
+func backup(path string) error {
+	// without a current file, the old backup is kept
+	if _, err := os.Stat(path); err != nil && os.IsNotExist(err) {
+		return nil
+	}
+
+	backupPath := BackupPath(path)
+	if err := os.Remove(backupPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.Link(path, backupPath); err == nil {
+		return nil
+	}
+
+	// hard links are not supported everywhere
+	source, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+
+	info, err := source.Stat()
+	if err != nil {
+		return err
+	}
+	destination, err := os.OpenFile(backupPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(destination, source); err != nil {
+		destination.Close()
+		return fmt.Errorf("Error creating backup of %s: %s", path, err.Error())
+	}
+	return destination.Close()
+}
+
+func writeAndSync(file *os.File, data []byte) error {
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
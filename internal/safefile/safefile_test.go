@@ -0,0 +1,164 @@
+package safefile
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileReplacesTarget(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	assertContent(t, path, "new")
+	// the temporary file was renamed, nothing is left next to the target
+	assertFiles(t, dir, "data.json")
+}
+
+func TestWriteFileUsesPermissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := WriteFile(path, []byte("new"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected permissions 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	// a file cannot be renamed over a directory which is not empty
+	path := filepath.Join(dir, "data")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "original"), []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFile(path, []byte("new"), 0644); err == nil {
+		t.Fatal("expected an error when replacing a directory")
+	}
+
+	assertContent(t, filepath.Join(path, "original"), "old")
+	// the temporary file is removed again
+	assertFiles(t, dir, "data")
+}
+
+func TestWriteFileWithBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	// without a file, there is nothing to back up
+	if err := WriteFileWithBackup(path, []byte("first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assertFiles(t, dir, "data.json")
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileWithBackup(path, []byte("second"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, BackupPath(path), "first")
+
+	// the backup keeps the permissions of the file it was created from
+	info, err := os.Stat(BackupPath(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected the backup to keep the permissions 0600, got %o", info.Mode().Perm())
+	}
+
+	// the next write rotates the backup
+	if err := WriteFileWithBackup(path, []byte("third"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, path, "third")
+	assertContent(t, BackupPath(path), "second")
+	assertFiles(t, dir, "data.json", "data.json.bak")
+}
+
+func TestReadFileRestoresBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(path, []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(BackupPath(path), []byte("valid"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded string
+	err := ReadFile(path, func(data []byte) error {
+		if string(data) != "valid" {
+			return errors.New("Invalid data")
+		}
+		decoded = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded != "valid" {
+		t.Errorf("expected the backup to be decoded, got %q", decoded)
+	}
+	assertContent(t, path+".corrupted", "corrupted")
+}
+
+func TestReadFileWithoutFile(t *testing.T) {
+	called := false
+	err := ReadFile(filepath.Join(t.TempDir(), "missing.json"), func([]byte) error {
+		called = true
+		return nil
+	})
+	if err != nil || called {
+		t.Errorf("expected decode not to be called without an error, got %v", err)
+	}
+}
+
+func assertContent(t *testing.T, path string, expected string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != expected {
+		t.Errorf("expected %q in %s, got %q", expected, path, string(data))
+	}
+}
+
+func assertFiles(t *testing.T, dir string, expected ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if len(names) != len(expected) {
+		t.Fatalf("expected the files %v in %s, got %v", expected, dir, names)
+	}
+	for i := range names {
+		if names[i] != expected[i] {
+			t.Errorf("expected the files %v in %s, got %v", expected, dir, names)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package schema
+
+import (
+	"encoding/json"
+)
+
+// durationPattern matches the durations time.ParseDuration understands, like 1m30s
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+// datetimePattern matches the times of announcements and maintenance windows, like 2006-01-02 15:04
+const datetimePattern = `^[0-9]{4}-[0-9]{2}-[0-9]{2} [0-9]{2}:[0-9]{2}$`
+
+// slugExpression matches lowercase letters, digits and single dashes between them
+const slugExpression = `^[a-z0-9]+(-[a-z0-9]+)*$`
+
+// JSONSchema returns a JSON Schema (draft-07) of the config file described by the config structs of the packages.
+// Editors use it for autocompletion and validation of downtimerobot.yml.
+func JSONSchema(configs ...interface{}) ([]byte, error) {
+	result := fromConfigs(configs).toJSONSchema()
+	result["$schema"] = "http://json-schema.org/draft-07/schema#"
+	result["title"] = "downtimerobot.yml"
+	return json.MarshalIndent(result, "", "  ")
+}
+
+func (s *schema) toJSONSchema() map[string]interface{} {
+	result := make(map[string]interface{})
+
+	switch s.kind {
+	case objectKind:
+		properties := make(map[string]interface{})
+		for _, prop := range s.properties {
+			propSchema := *prop.schema
+			if len(prop.unless) > 0 {
+				// the format depends on a sibling, which can not be expressed
+				propSchema.format = ""
+			}
+			properties[prop.name] = propSchema.toJSONSchema()
+		}
+		result["type"] = "object"
+		result["properties"] = properties
+		result["additionalProperties"] = false
+	case mapKind:
+		result["type"] = "object"
+		result["additionalProperties"] = s.items.toJSONSchema()
+	case arrayKind:
+		result["type"] = "array"
+		result["items"] = s.items.toJSONSchema()
+	case durationKind:
+		// integers are nanoseconds
+		result["type"] = []string{"string", "integer"}
+		result["pattern"] = durationPattern
+	case stringKind, integerKind, numberKind, booleanKind:
+		result["type"] = string(s.kind)
+	}
+
+	if len(s.enum) > 0 && s.ignoreCase {
+		// enums are case sensitive, so the values are only suggested
+		result["examples"] = s.enum
+	} else if len(s.enum) > 0 {
+		result["enum"] = s.enum
+	}
+
+	switch s.format {
+	case regexpFormat:
+		result["format"] = "regex"
+	case datetimeFormat:
+		result["pattern"] = datetimePattern
+	case slugFormat:
+		result["pattern"] = slugExpression
+	}
+
+	if len(s.reserved) > 0 {
+		result["not"] = map[string]interface{}{"enum": s.reserved}
+	}
+
+	return result
+}
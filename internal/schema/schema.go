@@ -0,0 +1,165 @@
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+type kind string
+
+const (
+	objectKind   kind = "object"
+	mapKind      kind = "map"
+	arrayKind    kind = "array"
+	stringKind   kind = "string"
+	integerKind  kind = "integer"
+	numberKind   kind = "number"
+	booleanKind  kind = "boolean"
+	durationKind kind = "duration"
+	anyKind      kind = "any"
+)
+
+// Formats of string values, set with the validate struct tag
+const (
+	regexpFormat   = "regexp"
+	templateFormat = "template"
+	// datetimeFormat is the layout of announcements and maintenance windows
+	datetimeFormat = "datetime"
+	// slugFormat allows lowercase letters, digits and dashes, like the ids of services
+	slugFormat = "slug"
+)
+
+// schema describes the expected value at one position of the config file.
+// It is derived from the config structs of the packages, which the validate struct tag can refine:
+//
+//	validate:"regexp"                       the value is a regular expression
+//	validate:"regexp,unless=literal"        ... unless the sibling key literal is true
+//	validate:"template"                     the value is a text/template
+//	validate:"datetime"                     the value is a time like 2006-01-02 15:04
+//	validate:"oneof=json bbolt,ignorecase"  the value is one of the listed ones
+//	validate:"slug,notoneof=incidents"      the value is a slug but none of the listed ones
+type schema struct {
+	kind kind
+	// properties of objects in the order of the struct fields
+	properties []property
+	// items of arrays and values of maps
+	items      *schema
+	format     string
+	enum       []string
+	reserved   []string
+	ignoreCase bool
+}
+
+type property struct {
+	name   string
+	schema *schema
+	// unless is the key of a sibling boolean which disables the format check
+	unless string
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// fromConfigs merges the top level keys of the config structs into one object
+func fromConfigs(configs []interface{}) *schema {
+	result := &schema{kind: objectKind}
+	for _, config := range configs {
+		configSchema := fromType(reflect.TypeOf(config))
+		result.properties = append(result.properties, configSchema.properties...)
+	}
+	return result
+}
+
+func fromType(t reflect.Type) *schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		return &schema{kind: durationKind}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		result := &schema{kind: objectKind}
+		addFields(result, t)
+		return result
+	case reflect.Slice, reflect.Array:
+		return &schema{kind: arrayKind, items: fromType(t.Elem())}
+	case reflect.Map:
+		return &schema{kind: mapKind, items: fromType(t.Elem())}
+	case reflect.String:
+		return &schema{kind: stringKind}
+	case reflect.Bool:
+		return &schema{kind: booleanKind}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{kind: integerKind}
+	case reflect.Float32, reflect.Float64:
+		return &schema{kind: numberKind}
+	}
+	return &schema{kind: anyKind}
+}
+
+// addFields adds the fields of the struct type like viper decodes them, squashed structs are inlined
+func addFields(result *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if strings.Contains(field.Tag.Get("mapstructure"), ",squash") {
+			addFields(result, field.Type)
+			continue
+		}
+		if !field.IsExported() || (field.Anonymous && field.Type.Kind() == reflect.Interface) {
+			continue
+		}
+
+		fieldSchema := fromType(field.Type)
+		prop := property{name: getKey(field), schema: fieldSchema}
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			switch {
+			case rule == regexpFormat || rule == templateFormat || rule == datetimeFormat || rule == slugFormat:
+				fieldSchema.format = rule
+			case strings.HasPrefix(rule, "notoneof="):
+				fieldSchema.reserved = strings.Fields(strings.TrimPrefix(rule, "notoneof="))
+			case strings.HasPrefix(rule, "oneof="):
+				fieldSchema.enum = strings.Fields(strings.TrimPrefix(rule, "oneof="))
+			case rule == "ignorecase":
+				fieldSchema.ignoreCase = true
+			case strings.HasPrefix(rule, "unless="):
+				prop.unless = strings.TrimPrefix(rule, "unless=")
+			}
+		}
+		result.properties = append(result.properties, prop)
+	}
+}
+
+// getKey returns the key of the field in the config file, which is its json name.
+// Fields without one use their name with a lower case first letter, like HTTPS becomes https.
+func getKey(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; len(name) > 0 {
+		return name
+	}
+	if strings.ToUpper(field.Name) == field.Name {
+		return strings.ToLower(field.Name)
+	}
+	runes := []rune(field.Name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+// getProperty returns the property with the key and whether its spelling matched exactly.
+// Viper ignores the case of keys, so they match in any case.
+func (s *schema) getProperty(key string) (*property, bool) {
+	for i := range s.properties {
+		if s.properties[i].name == key {
+			return &s.properties[i], true
+		}
+	}
+	for i := range s.properties {
+		if strings.EqualFold(s.properties[i].name, key) {
+			return &s.properties[i], false
+		}
+	}
+	return nil, false
+}
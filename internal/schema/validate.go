@@ -0,0 +1,325 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+var slugPattern = regexp.MustCompile(slugExpression)
+
+// Problem is a mistake in the config file. Warnings describe values that work but are likely not intended.
+type Problem struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+	Warning bool
+}
+
+// validator collects the problems while walking the document
+type validator struct {
+	problems []Problem
+}
+
+// ValidateFile checks the config file against the config structs of the packages
+func ValidateFile(path string, configs ...interface{}) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Validate(data, configs...), nil
+}
+
+// Validate checks the YAML document against the config structs of the packages
+func Validate(data []byte, configs ...interface{}) []Problem {
+	v := &validator{}
+
+	document := &yaml.Node{}
+	if err := yaml.Unmarshal(data, document); err != nil {
+		v.addError(0, 0, "", "%s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return v.problems
+	}
+	if len(document.Content) == 0 {
+		return v.problems
+	}
+
+	v.validate(document.Content[0], fromConfigs(configs), "")
+	return v.problems
+}
+
+func (v *validator) validate(node *yaml.Node, s *schema, path string) {
+	node = resolveAlias(node)
+	if node.Tag == "!!null" || s.kind == anyKind {
+		return
+	}
+
+	switch s.kind {
+	case objectKind:
+		v.validateObject(node, s, path)
+	case mapKind:
+		if v.expect(node, yaml.MappingNode, path, "a mapping") {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				v.validate(node.Content[i+1], s.items, joinPath(path, node.Content[i].Value))
+			}
+		}
+	case arrayKind:
+		if v.expect(node, yaml.SequenceNode, path, "a list") {
+			for i, item := range node.Content {
+				v.validate(item, s.items, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	default:
+		if v.expect(node, yaml.ScalarNode, path, "a single value") {
+			v.validateScalar(node, s, path)
+		}
+	}
+}
+
+func (v *validator) validateObject(node *yaml.Node, s *schema, path string) {
+	if !v.expect(node, yaml.MappingNode, path, "a mapping") {
+		return
+	}
+
+	// keys merged with << may be overridden, so only the keys of the mapping itself must be unique
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.Tag != "!!merge" && seen[strings.ToLower(key.Value)] {
+			v.addError(key.Line, key.Column, joinPath(path, key.Value), "Duplicate key %s", key.Value)
+		}
+		seen[strings.ToLower(key.Value)] = true
+	}
+
+	for _, pair := range getPairs(node) {
+		key, value := pair[0], pair[1]
+		keyPath := joinPath(path, key.Value)
+
+		prop, exact := s.getProperty(key.Value)
+		if prop == nil {
+			message := fmt.Sprintf("Unknown key %s", key.Value)
+			if suggestion := s.suggest(key.Value); len(suggestion) > 0 {
+				message += fmt.Sprintf(", did you mean %s?", suggestion)
+			}
+			v.addError(key.Line, key.Column, keyPath, "%s", message)
+			continue
+		}
+		if !exact {
+			v.addWarning(key.Line, key.Column, keyPath, "Key %s should be spelled %s", key.Value, prop.name)
+		}
+
+		if len(prop.unless) > 0 && isTrue(getValue(node, prop.unless)) {
+			withoutFormat := *prop.schema
+			withoutFormat.format = ""
+			v.validate(value, &withoutFormat, keyPath)
+			continue
+		}
+		v.validate(value, prop.schema, keyPath)
+	}
+}
+
+func (v *validator) validateScalar(node *yaml.Node, s *schema, path string) {
+	value := node.Value
+	switch s.kind {
+	case integerKind:
+		if _, err := strconv.ParseInt(value, 0, 64); err != nil {
+			v.addError(node.Line, node.Column, path, "Expected an integer but got %q", value)
+		}
+	case numberKind:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			v.addError(node.Line, node.Column, path, "Expected a number but got %q", value)
+		}
+	case booleanKind:
+		if _, ok := parseBool(value); !ok {
+			v.addError(node.Line, node.Column, path, "Expected true or false but got %q", value)
+		}
+	case durationKind:
+		if node.Tag == "!!int" {
+			v.addWarning(node.Line, node.Column, path, "Durations without a unit are nanoseconds, use a unit like 30s")
+		} else if _, err := time.ParseDuration(value); err != nil {
+			v.addError(node.Line, node.Column, path, "Expected a duration like 30s or 5m but got %q", value)
+		}
+	}
+
+	if len(s.enum) > 0 && !s.isInEnum(value) {
+		v.addError(node.Line, node.Column, path, "Expected one of %s but got %q", strings.Join(s.enum, ", "), value)
+	}
+	if s.isReserved(value) {
+		v.addError(node.Line, node.Column, path, "%q is reserved", value)
+	}
+
+	switch s.format {
+	case regexpFormat:
+		if _, err := regexp.Compile(value); err != nil {
+			v.addError(node.Line, node.Column, path, "Invalid regular expression: %s", err.Error())
+		}
+	case templateFormat:
+		// notifications parse the template like this
+		if _, err := template.New("t").Parse(value); err != nil {
+			v.addError(node.Line, node.Column, path, "Invalid template: %s", err.Error())
+		}
+	case datetimeFormat:
+		if _, err := time.Parse("2006-01-02 15:04", value); err != nil {
+			v.addError(node.Line, node.Column, path, "Expected a time like 2006-01-02 15:04 but got %q", value)
+		}
+	case slugFormat:
+		if !slugPattern.MatchString(value) {
+			v.addError(node.Line, node.Column, path, "Expected lowercase letters, digits and dashes but got %q", value)
+		}
+	}
+}
+
+// expect reports an error if the node is not of the kind
+func (v *validator) expect(node *yaml.Node, kind yaml.Kind, path string, description string) bool {
+	if node.Kind == kind {
+		return true
+	}
+	v.addError(node.Line, node.Column, path, "Expected %s", description)
+	return false
+}
+
+func (v *validator) addError(line int, column int, path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{line, column, path, fmt.Sprintf(format, args...), false})
+}
+
+func (v *validator) addWarning(line int, column int, path string, format string, args ...interface{}) {
+	v.problems = append(v.problems, Problem{line, column, path, fmt.Sprintf(format, args...), true})
+}
+
+// == schema ==
+
+func (s *schema) isInEnum(value string) bool {
+	for _, allowed := range s.enum {
+		if allowed == value || (s.ignoreCase && strings.EqualFold(allowed, value)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *schema) isReserved(value string) bool {
+	for _, reserved := range s.reserved {
+		if strings.EqualFold(reserved, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// suggest returns the property which is closest to the unknown key, if it is close enough to be a typo
+func (s *schema) suggest(key string) string {
+	suggestion := ""
+	best := len(key)/3 + 1
+	for _, prop := range s.properties {
+		if distance := levenshtein(strings.ToLower(key), strings.ToLower(prop.name)); distance <= best {
+			suggestion = prop.name
+			best = distance - 1
+		}
+	}
+	return suggestion
+}
+
+func levenshtein(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// == yaml ==
+
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	return node
+}
+
+// getPairs returns the key value pairs of the mapping, including those merged with <<
+func getPairs(node *yaml.Node) [][2]*yaml.Node {
+	pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Tag != "!!merge" {
+			pairs = append(pairs, [2]*yaml.Node{key, value})
+			continue
+		}
+
+		merged := []*yaml.Node{value}
+		if resolveAlias(value).Kind == yaml.SequenceNode {
+			merged = resolveAlias(value).Content
+		}
+		for _, mergedNode := range merged {
+			if mergedNode = resolveAlias(mergedNode); mergedNode.Kind == yaml.MappingNode {
+				pairs = append(pairs, getPairs(mergedNode)...)
+			}
+		}
+	}
+	return pairs
+}
+
+// getValue returns the value of the key in the mapping or nil
+func getValue(node *yaml.Node, key string) *yaml.Node {
+	for _, pair := range getPairs(node) {
+		if strings.EqualFold(pair[0].Value, key) {
+			return resolveAlias(pair[1])
+		}
+	}
+	return nil
+}
+
+func isTrue(node *yaml.Node) bool {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return false
+	}
+	value, _ := parseBool(node.Value)
+	return value
+}
+
+// parseBool also accepts yes, no, on and off, which viper reads as booleans
+func parseBool(value string) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "yes", "on", "y":
+		return true, true
+	case "no", "off", "n":
+		return false, true
+	}
+	result, err := strconv.ParseBool(value)
+	return result, err == nil
+}
+
+func joinPath(path string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
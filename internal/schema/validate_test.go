@@ -0,0 +1,155 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Interval time.Duration `json:"interval"`
+	Services []testService `json:"services"`
+}
+
+type testService struct {
+	ID         string `json:"id" validate:"slug,notoneof=incidents"`
+	Port       int    `json:"port"`
+	Pattern    string `json:"pattern" validate:"regexp,unless=literal"`
+	Literal    bool   `json:"literal"`
+	Template   string `json:"template" validate:"template"`
+	Time       string `json:"time" validate:"datetime"`
+	RecordType string `json:"recordType" validate:"oneof=A AAAA,ignorecase"`
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		yaml     string
+		expected []Problem
+	}{
+		{"valid", `
+interval: 1m
+services:
+  - id: api
+    port: 443
+    pattern: "up|ok"
+    template: "{{ .Name }} is down"
+    time: 2022-01-01 12:00
+    recordType: aaaa
+`, nil},
+		{"unknown key with suggestion", `
+intervall: 1m
+`, []Problem{{Line: 2, Path: "intervall", Message: "did you mean interval?"}}},
+		{"unknown key without suggestion", `
+services:
+  - hostname: example.com
+`, []Problem{{Line: 3, Path: "services[0].hostname", Message: "Unknown key hostname"}}},
+		{"case mismatch", `
+services:
+  - ID: api
+`, []Problem{{Line: 3, Path: "services[0].ID", Message: "should be spelled id", Warning: true}}},
+		{"duplicate key", `
+interval: 1m
+Interval: 2m
+`, []Problem{
+			{Line: 3, Path: "Interval", Message: "Duplicate key Interval"},
+			{Line: 3, Path: "Interval", Message: "should be spelled interval", Warning: true},
+		}},
+		{"merged keys may be overridden", `
+services:
+  - &base
+    id: api
+    port: 80
+  - <<: *base
+    id: web
+`, nil},
+		{"unknown merged key", `
+services:
+  - &base
+    prot: 80
+  - <<: *base
+    id: web
+`, []Problem{
+			{Line: 4, Path: "services[0].prot", Message: "did you mean port?"},
+			{Line: 4, Path: "services[1].prot", Message: "did you mean port?"},
+		}},
+		{"invalid regular expression", `
+services:
+  - pattern: "(up"
+`, []Problem{{Line: 3, Path: "services[0].pattern", Message: "Invalid regular expression"}}},
+		{"literal pattern is no regular expression", `
+services:
+  - pattern: "(up"
+    literal: yes
+`, nil},
+		{"duration without unit", `
+interval: 30
+`, []Problem{{Line: 2, Path: "interval", Message: "nanoseconds", Warning: true}}},
+		{"invalid duration", `
+interval: soon
+`, []Problem{{Line: 2, Path: "interval", Message: "Expected a duration"}}},
+		{"invalid datetime", `
+services:
+  - time: 01.01.2022
+`, []Problem{{Line: 3, Path: "services[0].time", Message: "Expected a time"}}},
+		{"invalid template", `
+services:
+  - template: "{{ .Name"
+`, []Problem{{Line: 3, Path: "services[0].template", Message: "Invalid template"}}},
+		{"invalid slug", `
+services:
+  - id: My API
+`, []Problem{{Line: 3, Path: "services[0].id", Message: "Expected lowercase letters"}}},
+		{"reserved slug", `
+services:
+  - id: incidents
+`, []Problem{{Line: 3, Path: "services[0].id", Message: "is reserved"}}},
+		{"value not in enum", `
+services:
+  - recordType: MX
+`, []Problem{{Line: 3, Path: "services[0].recordType", Message: "Expected one of A, AAAA"}}},
+		{"invalid integer", `
+services:
+  - port: https
+`, []Problem{{Line: 3, Path: "services[0].port", Message: "Expected an integer"}}},
+		{"mapping instead of list", `
+services:
+  id: api
+`, []Problem{{Line: 3, Path: "services", Message: "Expected a list"}}},
+		{"invalid yaml", `
+services: [
+`, []Problem{{Line: 0, Path: "", Message: "did not find expected node content"}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			problems := Validate([]byte(test.yaml), &testConfig{})
+			if len(problems) != len(test.expected) {
+				t.Fatalf("expected %d problems, got %+v", len(test.expected), problems)
+			}
+			for i, expected := range test.expected {
+				actual := problems[i]
+				if actual.Line != expected.Line || actual.Path != expected.Path || actual.Warning != expected.Warning || !strings.Contains(actual.Message, expected.Message) {
+					t.Errorf("expected %+v, got %+v", expected, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"interval", "interval", 0},
+		{"intervall", "interval", 1},
+		{"prot", "port", 2},
+		{"", "port", 4},
+	} {
+		if distance := levenshtein(test.a, test.b); distance != test.distance {
+			t.Errorf("expected distance %d between %q and %q, got %d", test.distance, test.a, test.b, distance)
+		}
+	}
+}
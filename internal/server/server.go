@@ -0,0 +1,215 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dorianim/downtimerobot/internal/announcements"
+	"github.com/dorianim/downtimerobot/internal/statistics"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
+)
+
+type config struct {
+	Server serverConfig `json:"server"`
+}
+
+type serverConfig struct {
+	Disabled bool   `json:"disabled"`
+	Address  string `json:"address"`
+	// BasePath is the path under which a reverse proxy forwards to the server, like /status
+	BasePath string `json:"basePath"`
+}
+
+// Data is everything the frontend needs, it is generated after every crawl
+type Data struct {
+	ServiceList       statistics.ServiceList
+	ServiceDetailList []statistics.ServiceDetails
+	AnnouncementList  *announcements.Announcements
+	IncidentList      statistics.IncidentList
+	// Metrics are in the Prometheus text format
+	Metrics []byte
+}
+
+// Server serves the generated frontend in ./public and the latest Data as JSON
+type Server struct {
+	config   serverConfig
+	mutex    sync.RWMutex
+	data     *Data
+	modified time.Time
+}
+
+const (
+	defaultServerAddress = ":8080"
+	publicDirectory      = "./public"
+	shutdownTimeout      = 10 * time.Second
+)
+
+// New returns a server for the configuration or nil if it is disabled
+func New() (*Server, error) {
+	conf, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if conf.Server.Disabled {
+		return nil, nil
+	}
+	return &Server{config: conf.Server}, nil
+}
+
+// Update replaces the data served by the JSON endpoints
+func (server *Server) Update(data Data) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.data = &data
+	server.modified = time.Now()
+}
+
+// Run serves until ctx is done and shuts down gracefully afterwards
+func (server *Server) Run(ctx context.Context) error {
+	httpServer := &http.Server{
+		Addr:    server.config.getAddress(),
+		Handler: server.handler(),
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.WithFields(log.Fields{
+			"address":  httpServer.Addr,
+			"basePath": server.config.getBasePath(),
+		}).Info("Serving the status page")
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+func (server *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/data/", server.serveData)
+	mux.HandleFunc("/metrics", server.serveMetrics)
+	mux.Handle("/", http.FileServer(http.Dir(publicDirectory)))
+
+	basePath := server.config.getBasePath()
+	if len(basePath) == 0 {
+		return mux
+	}
+
+	root := http.NewServeMux()
+	root.Handle(basePath+"/", http.StripPrefix(basePath, mux))
+	// the frontend uses relative paths, so the base path needs a trailing slash
+	root.Handle(basePath, http.RedirectHandler(basePath+"/", http.StatusMovedPermanently))
+	return root
+}
+
+// serveData serves the JSON files of the frontend from the latest data.
+// Clients can use the ETag and Last-Modified headers for conditional requests.
+func (server *Server) serveData(w http.ResponseWriter, r *http.Request) {
+	server.mutex.RLock()
+	data := server.data
+	modified := server.modified
+	server.mutex.RUnlock()
+
+	if data == nil {
+		http.Error(w, "No data yet, the first crawl is still running", http.StatusServiceUnavailable)
+		return
+	}
+
+	name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/data/"), ".json")
+	content := data.get(name)
+	if content == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := json.MarshalIndent(content, "", " ")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	hash := sha1.Sum(body)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(hash[:])+`"`)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, name+".json", modified, bytes.NewReader(body))
+}
+
+// serveMetrics serves the metrics of the latest data for Prometheus
+func (server *Server) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	server.mutex.RLock()
+	data := server.data
+	server.mutex.RUnlock()
+
+	if data == nil {
+		http.Error(w, "No data yet, the first crawl is still running", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(data.Metrics)
+}
+
+// get returns the content of the data file with the name, like frontend.Generate writes it
+func (data *Data) get(name string) interface{} {
+	switch name {
+	case "serviceList":
+		return data.ServiceList
+	case "announcementList":
+		return data.AnnouncementList
+	case "incidents":
+		return data.IncidentList
+	}
+
+	for _, serviceDetails := range data.ServiceDetailList {
+		if serviceDetails.Service.ID == name {
+			return serviceDetails
+		}
+	}
+	return nil
+}
+
+// EmptyConfig returns the server config for the schema
+func EmptyConfig() interface{} {
+	return &config{}
+}
+
+func loadConfig() (*config, error) {
+	conf := &config{}
+	if err := viper.Unmarshal(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+// == serverConfig ==
+
+func (conf serverConfig) getAddress() string {
+	if len(conf.Address) == 0 {
+		return defaultServerAddress
+	}
+	return conf.Address
+}
+
+// getBasePath returns the base path with a leading but without a trailing slash
+func (conf serverConfig) getBasePath() string {
+	basePath := strings.Trim(conf.BasePath, "/")
+	if len(basePath) == 0 {
+		return ""
+	}
+	return "/" + basePath
+}
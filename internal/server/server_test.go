@@ -0,0 +1,129 @@
+package server
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dorianim/downtimerobot/internal/statistics"
+)
+
+func newTestData(name string) Data {
+	details := statistics.ServiceDetails{}
+	details.Service.ID = "api"
+	details.Service.Name = name
+	return Data{
+		ServiceList:       statistics.ServiceList{Services: []statistics.Service{details.Service.Service}},
+		ServiceDetailList: []statistics.ServiceDetails{details},
+		Metrics:           []byte("downtimerobot_service_up{id=\"api\"} 1\n"),
+	}
+}
+
+func get(t *testing.T, url string, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	// redirects are checked by the tests
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestServeDataWithoutData(t *testing.T) {
+	server := &Server{}
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	for _, path := range []string{"/data/serviceList.json", "/metrics"} {
+		if resp := get(t, httpServer.URL+path, nil); resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("expected status %d for %s before the first crawl, got %d", http.StatusServiceUnavailable, path, resp.StatusCode)
+		}
+	}
+}
+
+func TestServeDataConditionalRequests(t *testing.T) {
+	server := &Server{}
+	server.Update(newTestData("API"))
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	resp := get(t, httpServer.URL+"/data/api.json", nil)
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if resp.StatusCode != http.StatusOK || len(etag) == 0 || len(lastModified) == 0 {
+		t.Fatalf("expected status 200 with ETag and Last-Modified, got %d, %q and %q", resp.StatusCode, etag, lastModified)
+	}
+
+	if resp := get(t, httpServer.URL+"/data/api.json", http.Header{"If-None-Match": {etag}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected status 304 for a matching ETag, got %d", resp.StatusCode)
+	}
+	if resp := get(t, httpServer.URL+"/data/api.json", http.Header{"If-Modified-Since": {lastModified}}); resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected status 304 if not modified since, got %d", resp.StatusCode)
+	}
+
+	// new data changes the ETag
+	server.Update(newTestData("Renamed API"))
+	resp = get(t, httpServer.URL+"/data/api.json", http.Header{"If-None-Match": {etag}})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("ETag") == etag {
+		t.Errorf("expected status 200 with a new ETag after an update, got %d and %q", resp.StatusCode, resp.Header.Get("ETag"))
+	}
+}
+
+func TestServeDataUnknownFile(t *testing.T) {
+	server := &Server{}
+	server.Update(newTestData("API"))
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	for _, path := range []string{"/data/missing.json", "/data/"} {
+		if resp := get(t, httpServer.URL+path, nil); resp.StatusCode != http.StatusNotFound {
+			t.Errorf("expected status 404 for %s, got %d", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestHandlerBasePath(t *testing.T) {
+	server := &Server{config: serverConfig{BasePath: "status/"}}
+	server.Update(newTestData("API"))
+	httpServer := httptest.NewServer(server.handler())
+	defer httpServer.Close()
+
+	tests := []struct {
+		path       string
+		statusCode int
+	}{
+		{"/status/data/serviceList.json", http.StatusOK},
+		{"/status/data/api.json", http.StatusOK},
+		{"/status/data/missing.json", http.StatusNotFound},
+		{"/status/metrics", http.StatusOK},
+		{"/data/serviceList.json", http.StatusNotFound},
+		{"/metrics", http.StatusNotFound},
+		{"/status", http.StatusMovedPermanently},
+	}
+	for _, test := range tests {
+		if resp := get(t, httpServer.URL+test.path, nil); resp.StatusCode != test.statusCode {
+			t.Errorf("expected status %d for %s, got %d", test.statusCode, test.path, resp.StatusCode)
+		}
+	}
+
+	resp := get(t, httpServer.URL+"/status", nil)
+	if location := resp.Header.Get("Location"); location != "/status/" {
+		t.Errorf("expected a redirect to /status/, got %q", location)
+	}
+
+	resp = get(t, httpServer.URL+"/status/metrics", nil)
+	if body, _ := io.ReadAll(resp.Body); string(body) != "downtimerobot_service_up{id=\"api\"} 1\n" {
+		t.Errorf("expected the metrics of the data, got %q", string(body))
+	}
+}
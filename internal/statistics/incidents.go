@@ -0,0 +1,242 @@
+package statistics
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dorianim/downtimerobot/internal/announcements"
+	"github.com/dorianim/downtimerobot/internal/crawler"
+)
+
+type IncidentList struct {
+	Incidents []Incident `json:"incidents"`
+	TimeZone  string     `json:"timeZone"`
+}
+
+// Incident is a period in which at least one service was down.
+// Overlapping down periods of several services are merged into one incident.
+type Incident struct {
+	Start           int64                        `json:"start"`
+	End             int64                        `json:"end"`
+	Ongoing         bool                         `json:"ongoing"`
+	StartTimeString string                       `json:"startTimeString"`
+	EndTimeString   string                       `json:"endTimeString"`
+	DurationString  string                       `json:"durationString"`
+	StatusMessage   string                       `json:"statusMessage"`
+	Services        []IncidentService            `json:"services"`
+	Announcements   []announcements.Announcement `json:"announcements"`
+}
+
+type IncidentService struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Host   string `json:"host"`
+	Status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"status"`
+}
+
+// downPeriod is a contiguous period in which a single service was down
+type downPeriod struct {
+	service       crawler.Service
+	statusCode    int
+	statusMessage string
+	start         int64
+	end           int64
+	ongoing       bool
+}
+
+// GenerateIncidents derives the incidents of the last 90 days from the historic data of the services
+// and links the announcements published while they were ongoing
+func GenerateIncidents(crawledServices []crawler.Service, announcementList *announcements.Announcements) IncidentList {
+	now := time.Now()
+	periods := make([]downPeriod, 0)
+	for _, crawledService := range crawledServices {
+		periods = append(periods, getDownPeriods(crawledService, now.AddDate(0, 0, -90).Unix())...)
+	}
+
+	incidents := mergeDownPeriods(periods, now)
+	if announcementList != nil {
+		linkAnnouncements(incidents, announcementList.Announcements)
+	}
+
+	return IncidentList{Incidents: incidents, TimeZone: now.Format("-07:00")}
+}
+
+// getDownPeriods returns the periods in which the confirmed state of the service was down,
+// including those which were downsampled into rollups
+func getDownPeriods(crawledService crawler.Service, since int64) []downPeriod {
+	historicData := crawledService.GetHistoricData()
+	transitions := crawler.GetStateTransitions(historicData, crawledService.GetStateOptions())
+
+	// the first data point is no transition but may already be down
+	for _, dataPoint := range historicData {
+		if !dataPoint.IsDisabled() && !dataPoint.IsMaintenance() && dataPoint.GetRollup() == nil {
+			if crawler.GetState(dataPoint) == crawler.StateDown {
+				transitions = append([]crawler.HistoricDataPoint{dataPoint}, transitions...)
+			}
+			break
+		}
+	}
+
+	periods := getRollupDownPeriods(crawledService)
+	var current *downPeriod
+	for _, transition := range transitions {
+		down := crawler.GetState(transition) == crawler.StateDown
+		if down && current == nil {
+			current = &downPeriod{
+				service:       crawledService,
+				statusCode:    transition.GetStatusCode(),
+				statusMessage: transition.GetStatusMessage(),
+				start:         transition.GetTimestamp(),
+			}
+		} else if !down && current != nil {
+			current.end = transition.GetTimestamp()
+			periods = append(periods, *current)
+			current = nil
+		}
+	}
+	if current != nil {
+		current.ongoing = true
+		periods = append(periods, *current)
+	}
+
+	result := make([]downPeriod, 0)
+	for _, period := range mergeServiceDownPeriods(periods) {
+		if period.ongoing || period.end >= since {
+			result = append(result, period)
+		}
+	}
+	return result
+}
+
+// getRollupDownPeriods returns the rollups in which the service was down at least as often as
+// its failure threshold. The exact times are lost, so the whole period of the rollup is used.
+func getRollupDownPeriods(crawledService crawler.Service) []downPeriod {
+	threshold := crawledService.GetStateOptions().FailureThreshold
+	if threshold < 1 {
+		threshold = 1
+	}
+
+	historicData := crawledService.GetHistoricData()
+	periods := make([]downPeriod, 0)
+	for i, dataPoint := range historicData {
+		rollup := dataPoint.GetRollup()
+		if rollup == nil || rollup.Down < threshold {
+			continue
+		}
+
+		end := dataPoint.GetTimestamp() + rollup.Period
+		if i+1 < len(historicData) && historicData[i+1].GetTimestamp() < end {
+			end = historicData[i+1].GetTimestamp()
+		}
+		periods = append(periods, downPeriod{
+			service:       crawledService,
+			statusMessage: fmt.Sprintf("Down in %d of %d checks", rollup.Down, rollup.Up+rollup.Down),
+			start:         dataPoint.GetTimestamp(),
+			end:           end,
+		})
+	}
+	return periods
+}
+
+// mergeServiceDownPeriods merges the adjacent periods of one service, like consecutive rollups
+// and the raw data points following them. The status of the latest period is kept.
+func mergeServiceDownPeriods(periods []downPeriod) []downPeriod {
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].start < periods[j].start
+	})
+
+	merged := make([]downPeriod, 0, len(periods))
+	for _, period := range periods {
+		last := len(merged) - 1
+		if last < 0 || merged[last].ongoing || period.start > merged[last].end {
+			merged = append(merged, period)
+			continue
+		}
+
+		start := merged[last].start
+		if period.ongoing || period.end > merged[last].end {
+			merged[last] = period
+		}
+		merged[last].start = start
+	}
+	return merged
+}
+
+// mergeDownPeriods merges overlapping periods into incidents, the newest incident comes first
+func mergeDownPeriods(periods []downPeriod, now time.Time) []Incident {
+	sort.SliceStable(periods, func(i, j int) bool {
+		return periods[i].start < periods[j].start
+	})
+
+	incidents := make([]Incident, 0)
+	for _, period := range periods {
+		last := len(incidents) - 1
+		if last < 0 || (!incidents[last].Ongoing && period.start > incidents[last].End) {
+			incidents = append(incidents, Incident{
+				Start:         period.start,
+				StatusMessage: period.statusMessage,
+				Services:      make([]IncidentService, 0),
+				Announcements: make([]announcements.Announcement, 0),
+			})
+			last++
+		}
+
+		incident := &incidents[last]
+		if period.ongoing {
+			incident.Ongoing = true
+		} else if period.end > incident.End {
+			incident.End = period.end
+		}
+		incident.Services = append(incident.Services, newIncidentService(period))
+	}
+
+	for i := range incidents {
+		incident := &incidents[i]
+		end := time.Unix(incident.End, 0)
+		if incident.Ongoing {
+			incident.End = 0
+			end = now
+		} else {
+			incident.EndTimeString = end.Format("January 02, 2006, 15:04")
+		}
+		incident.StartTimeString = time.Unix(incident.Start, 0).Format("January 02, 2006, 15:04")
+		incident.DurationString = durationAsString(time.Unix(incident.Start, 0), end)
+	}
+
+	sort.SliceStable(incidents, func(i, j int) bool {
+		return incidents[i].Start > incidents[j].Start
+	})
+	return incidents
+}
+
+func newIncidentService(period downPeriod) IncidentService {
+	incidentService := IncidentService{}
+	incidentService.ID = period.service.GetID()
+	incidentService.Name = period.service.GetName()
+	incidentService.Host = period.service.GetHost()
+	// rollups do not know the status code, it is 0 then
+	incidentService.Status.Code = period.statusCode
+	incidentService.Status.Message = period.statusMessage
+	return incidentService
+}
+
+// linkAnnouncements adds all announcements published during an incident to it
+func linkAnnouncements(incidents []Incident, announcementList []announcements.Announcement) {
+	now := time.Now().Unix()
+	for i := range incidents {
+		end := incidents[i].End
+		if incidents[i].Ongoing {
+			end = now
+		}
+
+		for _, announcement := range announcementList {
+			if announcement.Timestamp >= incidents[i].Start && announcement.Timestamp <= end {
+				incidents[i].Announcements = append(incidents[i].Announcements, announcement)
+			}
+		}
+	}
+}
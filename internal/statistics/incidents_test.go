@@ -0,0 +1,78 @@
+package statistics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dorianim/downtimerobot/internal/crawler"
+)
+
+// incidentTestService only has the historic data the incidents are derived from
+type incidentTestService struct {
+	crawler.Service
+	historicData []crawler.HistoricDataPoint
+}
+
+func (service incidentTestService) GetID() string   { return "api" }
+func (service incidentTestService) GetName() string { return "API" }
+func (service incidentTestService) GetHost() string { return "api.example" }
+func (service incidentTestService) GetStateOptions() crawler.StateOptions {
+	return crawler.StateOptions{}
+}
+func (service incidentTestService) GetHistoricData() []crawler.HistoricDataPoint {
+	return service.historicData
+}
+
+// incidentTestDataPoint is a single check or, if rollup is set, the aggregate of several checks
+type incidentTestDataPoint struct {
+	crawler.HistoricDataPoint
+	timestamp int64
+	up        bool
+	rollup    *crawler.Rollup
+}
+
+func checkAt(timestamp int64, up bool) incidentTestDataPoint {
+	return incidentTestDataPoint{timestamp: timestamp, up: up}
+}
+
+func rollupAt(timestamp int64, up int, down int) incidentTestDataPoint {
+	return incidentTestDataPoint{timestamp: timestamp, up: true, rollup: &crawler.Rollup{Period: 3600, Up: up, Down: down}}
+}
+
+func (dataPoint incidentTestDataPoint) IsUp() bool                 { return dataPoint.up }
+func (dataPoint incidentTestDataPoint) IsDegraded() bool           { return false }
+func (dataPoint incidentTestDataPoint) IsDisabled() bool           { return false }
+func (dataPoint incidentTestDataPoint) IsMaintenance() bool        { return false }
+func (dataPoint incidentTestDataPoint) GetStatusCode() int         { return 600 }
+func (dataPoint incidentTestDataPoint) GetStatusMessage() string   { return "Request error" }
+func (dataPoint incidentTestDataPoint) GetTimestamp() int64        { return dataPoint.timestamp }
+func (dataPoint incidentTestDataPoint) GetRollup() *crawler.Rollup { return dataPoint.rollup }
+
+func TestGenerateIncidentsIncludesRollups(t *testing.T) {
+	hour := time.Now().Add(-30 * time.Hour).Truncate(time.Hour).Unix()
+	service := incidentTestService{historicData: []crawler.HistoricDataPoint{
+		// an incident which was downsampled completely
+		rollupAt(hour-5*3600, 50, 10),
+		rollupAt(hour-4*3600, 60, 0),
+		// an incident which started before the raw data points
+		rollupAt(hour, 30, 20),
+		checkAt(hour+1800, false),
+		checkAt(hour+3600, false),
+		checkAt(hour+7200, true),
+	}}
+
+	incidents := GenerateIncidents([]crawler.Service{service}, nil).Incidents
+	if len(incidents) != 2 {
+		t.Fatalf("expected 2 incidents, got %d", len(incidents))
+	}
+
+	if incidents[0].Start != hour || incidents[0].End != hour+7200 {
+		t.Errorf("expected the incident to last from %d to %d, got %d to %d", hour, hour+7200, incidents[0].Start, incidents[0].End)
+	}
+	if len(incidents[0].Services) != 1 {
+		t.Errorf("expected the service once, got %d times", len(incidents[0].Services))
+	}
+	if incidents[1].Start != hour-5*3600 || incidents[1].End != hour-4*3600 {
+		t.Errorf("expected the downsampled incident to last from %d to %d, got %d to %d", hour-5*3600, hour-4*3600, incidents[1].Start, incidents[1].End)
+	}
+}
@@ -11,26 +11,45 @@ import (
 // When chaging, also update types in statistics.ts
 
 type Service struct {
-	Name            string           `json:"name"`
-	Host            string           `json:"host"`
-	Type            string           `json:"type"`
-	Up              bool             `json:"up"`
-	Disabled        bool             `json:"disabled"`
-	Uptime          UptimeStatistics `json:"uptime"`
-	DailyStatistics [90]float32      `json:"dailyStatistics"`
-	logs            []ServiceLog
-	responseTimes   []ServiceResponseTime
+	ID               string           `json:"id"`
+	Name             string           `json:"name"`
+	Host             string           `json:"host"`
+	Type             string           `json:"type"`
+	Up               bool             `json:"up"`
+	Degraded         bool             `json:"degraded"`
+	Disabled         bool             `json:"disabled"`
+	Maintenance      bool             `json:"maintenance"`
+	Uptime           UptimeStatistics `json:"uptime"`
+	DailyStatistics  [90]float32      `json:"dailyStatistics"`
+	DailyDegradation [90]float32      `json:"dailyDegradation"`
+	DailyMaintenance [90]float32      `json:"dailyMaintenance"`
+	logs             []ServiceLog
+	responseTimes    []ServiceResponseTime
+	certificate      *ServiceCertificate
 }
 
 type DetailedService struct {
 	Service
 	Logs          []ServiceLog          `json:"logs"`
 	ResponseTimes []ServiceResponseTime `json:"responseTimes"`
+	// Certificate is only set for services which presented a TLS certificate in their last crawl
+	Certificate *ServiceCertificate `json:"certificate,omitempty"`
+}
+
+// ServiceCertificate is the certificate of the chain which expires first, it may be an intermediate one
+type ServiceCertificate struct {
+	Subject          string `json:"subject"`
+	Issuer           string `json:"issuer"`
+	Expiry           int64  `json:"expiry"`
+	ExpiryTimeString string `json:"expiryTimeString"`
+	DaysLeft         int    `json:"daysLeft"`
 }
 
 type ServiceLog struct {
 	Up             bool   `json:"up"`
+	Degraded       bool   `json:"degraded"`
 	Disabled       bool   `json:"disabled"`
+	Maintenance    bool   `json:"maintenance"`
 	TimeString     string `json:"timeString"`
 	DurationString string `json:"durationString"`
 	Status         struct {
@@ -65,10 +84,12 @@ type UptimeStatistics struct {
 }
 
 type CountStatistics struct {
-	Up       int `json:"up"`
-	Down     int `json:"down"`
-	Disabled int `json:"disabled"`
-	Total    int `json:"total"`
+	Up          int `json:"up"`
+	Degraded    int `json:"degraded"`
+	Down        int `json:"down"`
+	Disabled    int `json:"disabled"`
+	Maintenance int `json:"maintenance"`
+	Total       int `json:"total"`
 }
 
 type Statistics struct {
@@ -101,7 +122,7 @@ func generateServiceDetails(services []Service, dayStrings [90]string, timeZone
 		serviceDetails := ServiceDetails{}
 		serviceDetails.Days = dayStrings
 		serviceDetails.TimeZone = timeZone
-		serviceDetails.Service = DetailedService{service, service.logs, service.responseTimes}
+		serviceDetails.Service = DetailedService{service, service.logs, service.responseTimes, service.certificate}
 		serviceDetailsList = append(serviceDetailsList, serviceDetails)
 	}
 	return serviceDetailsList
@@ -112,53 +133,123 @@ func calculateAllServiceStatistics(crawledServices []crawler.Service) []Service
 	services := make([]Service, len(crawledServices))
 	for i, crawledService := range crawledServices {
 		services[i] = Service{}
+		services[i].ID = crawledService.GetID()
 		services[i].Name = crawledService.GetName()
 		services[i].Host = crawledService.GetHost()
 		services[i].Disabled = crawledService.IsDisabled()
 		services[i].Type = crawledService.GetType()
-		services[i].DailyStatistics = calculateServiceStatistics(crawledService)
+		services[i].DailyStatistics, services[i].DailyDegradation, services[i].DailyMaintenance = calculateServiceStatistics(crawledService)
 		services[i].Up = crawledService.IsUp()
+		services[i].Degraded = crawledService.IsDegraded()
+		services[i].Maintenance = isInMaintenance(crawledService)
 		services[i].Uptime = calculateServiceUptimeStatistics(services[i])
 		services[i].responseTimes = getServiceResponseTimes(crawledService)
 		services[i].logs = generateServiceLogs(crawledService)
+		services[i].certificate = getServiceCertificate(crawledService)
 	}
 	return services
 }
 
-func calculateServiceStatistics(crawledService crawler.Service) [90]float32 {
-	var result [90]float32
+func getServiceCertificate(crawledService crawler.Service) *ServiceCertificate {
+	certificateService, ok := crawledService.(crawler.CertificateService)
+	if !ok {
+		return nil
+	}
+	certificate, ok := certificateService.GetCertificate()
+	if !ok {
+		return nil
+	}
+
+	return &ServiceCertificate{
+		Subject:          certificate.Subject,
+		Issuer:           certificate.Issuer,
+		Expiry:           certificate.NotAfter.Unix(),
+		ExpiryTimeString: certificate.NotAfter.Local().Format("January 02, 2006, 15:04"),
+		DaysLeft:         certificate.GetDaysLeft(),
+	}
+}
+
+func calculateServiceStatistics(crawledService crawler.Service) ([90]float32, [90]float32, [90]float32) {
+	var uptime [90]float32
+	var degradation [90]float32
+	var maintenance [90]float32
 	now := time.Now()
 	tmpDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 	for i := 0; i < 90; i++ {
 		startDate := tmpDate.AddDate(0, 0, -i)
 		endDate := startDate.Add(time.Hour * 24)
-		result[i] = calculateUptime(getDataPointsBetween(
+		// rollups start at midnight, so the end is excluded to not count them twice
+		dataPoints := getDataPointsBetween(
 			startDate.Unix(),
-			endDate.Unix(),
+			endDate.Unix()-1,
 			crawledService.GetHistoricData(),
-		))
+		)
+		uptime[i], degradation[i] = calculateUptime(dataPoints)
+		maintenance[i] = calculateMaintenance(dataPoints)
 	}
-	return result
+	return uptime, degradation, maintenance
 }
 
-func calculateUptime(dataPoints []crawler.HistoricDataPoint) float32 {
+// calculateUptime returns the share of data points which were up and the share of those which were degraded.
+// Data points recorded during maintenance do not count, rollups count with all their data points.
+func calculateUptime(dataPoints []crawler.HistoricDataPoint) (float32, float32) {
 	if len(dataPoints) <= 0 {
-		return -1
+		return -1, -1
 	}
 
 	var sum float32 = 0.0
+	var degradedSum float32 = 0.0
 	var count int = 0
 	for _, datadataPoint := range dataPoints {
+		if rollup := datadataPoint.GetRollup(); rollup != nil {
+			count += rollup.Up + rollup.Down
+			sum += float32(rollup.Up)
+			degradedSum += float32(rollup.Degraded)
+			continue
+		}
+		if datadataPoint.IsMaintenance() {
+			continue
+		}
 		if !datadataPoint.IsDisabled() {
 			count++
 		}
 		if datadataPoint.IsUp() {
 			sum++
 		}
+		if datadataPoint.IsDegraded() {
+			degradedSum++
+		}
 	}
 
 	if count == 0 {
-		return -1
+		return -1, -1
+	}
+	if sum == 0 {
+		return 0, 0
+	}
+	return round(sum / float32(count)), round(degradedSum / sum)
+}
+
+// calculateMaintenance returns the share of data points which were recorded during maintenance
+func calculateMaintenance(dataPoints []crawler.HistoricDataPoint) float32 {
+	var sum float32 = 0.0
+	var count int = 0
+	for _, dataPoint := range dataPoints {
+		if rollup := dataPoint.GetRollup(); rollup != nil {
+			count += rollup.Up + rollup.Down + rollup.Maintenance
+			sum += float32(rollup.Maintenance)
+			continue
+		}
+		if !dataPoint.IsDisabled() {
+			count++
+		}
+		if dataPoint.IsMaintenance() {
+			sum++
+		}
+	}
+
+	if count == 0 {
+		return 0
 	}
 	return round(sum / float32(count))
 }
@@ -220,10 +311,13 @@ func generateServiceLogs(crawledService crawler.Service) []ServiceLog {
 	var previousServiceLog ServiceLog
 
 	for _, dataPoint := range dataPoints {
+		if dataPoint.GetRollup() != nil {
+			continue
+		}
 		generateServiceLog(dataPoint, &logs, &previousStatusCode, &previousLogTimestamp, &previousServiceLog)
 	}
 
-	if len(dataPoints) > 0 {
+	if len(dataPoints) > 0 && dataPoints[len(dataPoints)-1].GetRollup() == nil {
 		generateServiceLog(dataPoints[len(dataPoints)-1], &logs, nil, &previousLogTimestamp, &previousServiceLog)
 	}
 
@@ -241,7 +335,9 @@ func generateServiceLog(dataPoint crawler.HistoricDataPoint, logs *[]ServiceLog,
 
 	serviceLog := ServiceLog{}
 	serviceLog.Up = dataPoint.IsUp()
+	serviceLog.Degraded = dataPoint.IsDegraded()
 	serviceLog.Disabled = dataPoint.IsDisabled()
+	serviceLog.Maintenance = dataPoint.IsMaintenance()
 	serviceLog.TimeString = logTime.Format("January 02, 2006, 15:04")
 	serviceLog.Status.Code = dataPoint.GetStatusCode()
 	serviceLog.Status.Message = dataPoint.GetStatusMessage()
@@ -271,10 +367,14 @@ func calculateCountStatistics(services []Service) CountStatistics {
 	for _, service := range services {
 		if service.Disabled {
 			counts.Disabled++
-		} else if service.Up {
-			counts.Up++
-		} else {
+		} else if service.Maintenance {
+			counts.Maintenance++
+		} else if !service.Up {
 			counts.Down++
+		} else if service.Degraded {
+			counts.Degraded++
+		} else {
+			counts.Up++
 		}
 		counts.Total++
 	}
@@ -283,7 +383,7 @@ func calculateCountStatistics(services []Service) CountStatistics {
 
 func calculateUptimeStatistics(services []Service, counts CountStatistics) UptimeStatistics {
 	uptime := UptimeStatistics{}
-	totalServices := float32(counts.Up + counts.Down)
+	totalServices := float32(counts.Up + counts.Degraded + counts.Down + counts.Maintenance)
 	for _, service := range services {
 		if service.Disabled {
 			continue
@@ -299,6 +399,15 @@ func calculateUptimeStatistics(services []Service, counts CountStatistics) Uptim
 
 // == Helpers ==
 
+// isInMaintenance returns true if the latest data point of the service was recorded during maintenance
+func isInMaintenance(crawledService crawler.Service) bool {
+	historicData := crawledService.GetHistoricData()
+	if len(historicData) == 0 {
+		return false
+	}
+	return historicData[len(historicData)-1].IsMaintenance()
+}
+
 func durationAsString(from time.Time, to time.Time) string {
 	durationHours := (to.Unix() - from.Unix()) / (60 * 60)
 	durationMinutes := ((to.Unix() - from.Unix()) % (60 * 60)) / 60